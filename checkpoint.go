@@ -0,0 +1,121 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CheckpointStore persists the cursor of the last acknowledged record.
+type CheckpointStore interface {
+	// Load returns the saved cursor, or the zero Cursor if nothing was saved yet.
+	Load() (Cursor, error)
+	// Save persists the cursor.
+	Save(cursor Cursor) error
+}
+
+// MemCheckpoint keeps the cursor in memory. It is useful for periodic shippers
+// that only need to resume within the lifetime of a process.
+type MemCheckpoint struct {
+	mu     sync.Mutex
+	cursor Cursor
+}
+
+// Load returns the saved cursor.
+func (m *MemCheckpoint) Load() (Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cursor, nil
+}
+
+// Save stores the cursor.
+func (m *MemCheckpoint) Save(cursor Cursor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cursor = cursor
+	return nil
+}
+
+// FileCheckpoint persists the cursor to a file, so that replay can resume after a restart.
+type FileCheckpoint struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileCheckpoint creates a checkpoint store backed by the file at path.
+// The file is created on the first Save.
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+// Load reads the cursor from the file. A missing file yields the zero Cursor.
+func (f *FileCheckpoint) Load() (Cursor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid checkpoint file %s: %w", f.path, err)
+	}
+	return Cursor(seq), nil
+}
+
+// Save writes the cursor to the file. The write goes to a temporary file which is
+// then renamed over the checkpoint, so a crash never leaves a partial checkpoint.
+func (f *FileCheckpoint) Save(cursor Cursor) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.WriteString(strconv.FormatUint(uint64(cursor), 10) + "\n"); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// PlayFromCheckpoint outputs the logs appended since the checkpoint to the provided
+// handler, then saves the position of the last successfully handled record.
+//
+// The checkpoint is saved even when the handler fails part way, so the next call
+// retries from the failed record rather than replaying the acknowledged ones.
+func (c *LogCollector) PlayFromCheckpoint(ctx context.Context, handler slog.Handler, cp CheckpointStore, opts ...ReplayOption) error {
+	cursor, err := cp.Load()
+	if err != nil {
+		return fmt.Errorf("loading checkpoint: %w", err)
+	}
+
+	newCursor, playErr := c.PlayFrom(ctx, handler, cursor, opts...)
+	if newCursor != cursor {
+		if err := cp.Save(newCursor); err != nil {
+			return errors.Join(playErr, fmt.Errorf("saving checkpoint: %w", err))
+		}
+	}
+	return playErr
+}
@@ -0,0 +1,130 @@
+package loglater
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestFileCheckpoint(t *testing.T) {
+	t.Run("MissingFile", func(t *testing.T) {
+		cp := NewFileCheckpoint(filepath.Join(t.TempDir(), "cursor"))
+		cursor, err := cp.Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cursor != 0 {
+			t.Errorf("Expected zero cursor, got %d", cursor)
+		}
+	})
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cursor")
+		if err := NewFileCheckpoint(path).Save(42); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		cursor, err := NewFileCheckpoint(path).Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cursor != 42 {
+			t.Errorf("Expected cursor 42, got %d", cursor)
+		}
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("Expected only the checkpoint file, found %d entries", len(entries))
+		}
+	})
+
+	t.Run("CorruptFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cursor")
+		if err := os.WriteFile(path, []byte("not a number"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewFileCheckpoint(path).Load(); err == nil {
+			t.Error("Expected error for corrupt checkpoint file")
+		}
+	})
+
+	t.Run("MissingDirectory", func(t *testing.T) {
+		cp := NewFileCheckpoint(filepath.Join(t.TempDir(), "missing", "cursor"))
+		if err := cp.Save(1); err == nil {
+			t.Error("Expected error saving into a missing directory")
+		}
+	})
+}
+
+func TestPlayFromCheckpoint(t *testing.T) {
+	t.Run("ResumesAfterRestart", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cursor")
+
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("a")
+		logger.Info("b")
+
+		handler := &failOnMessageHandler{}
+		if err := collector.PlayFromCheckpoint(t.Context(), handler, NewFileCheckpoint(path)); err != nil {
+			t.Fatalf("PlayFromCheckpoint failed: %v", err)
+		}
+
+		// Simulate a restart: a new store continues numbering after the checkpoint
+		cp := NewFileCheckpoint(path)
+		saved, err := cp.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		store := storage.NewRecordStorage(storage.WithSequenceStart(uint64(saved)))
+		collector = NewLogCollector(nil, WithStorage(store))
+		slog.New(collector).Info("c")
+
+		if err := collector.PlayFromCheckpoint(t.Context(), handler, cp); err != nil {
+			t.Fatalf("PlayFromCheckpoint failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "a,b,c" {
+			t.Errorf("Expected a,b,c handled exactly once, got %s", got)
+		}
+	})
+
+	t.Run("SavesProgressOnFailure", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("a")
+		logger.Info("b")
+
+		cp := &MemCheckpoint{}
+		err := collector.PlayFromCheckpoint(t.Context(), &failOnMessageHandler{fail: "b"}, cp)
+		if !errors.Is(err, errHandlerFailed) {
+			t.Fatalf("Expected errHandlerFailed, got %v", err)
+		}
+
+		cursor, _ := cp.Load()
+		if cursor != 1 {
+			t.Errorf("Expected checkpoint at 1, got %d", cursor)
+		}
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cursor")
+		if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		collector := NewLogCollector(nil)
+		err := collector.PlayFromCheckpoint(t.Context(), &failOnMessageHandler{}, NewFileCheckpoint(path))
+		if err == nil || !strings.Contains(err.Error(), "loading checkpoint") {
+			t.Errorf("Expected loading checkpoint error, got %v", err)
+		}
+	})
+}
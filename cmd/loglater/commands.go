@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robbyt/go-loglater/ingest"
+	"github.com/robbyt/go-loglater/query"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// errUsage reports invalid flags or arguments, after the usage has been printed
+var errUsage = errors.New("usage error")
+
+// newFlagSet creates the flag set for a command, printing errors to stderr
+func newFlagSet(env *environment, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(env.stderr, "usage: loglater %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the command line, mapping flag errors to errUsage and a
+// request for help to a nil error with done set
+func parseFlags(fs *flag.FlagSet, args []string) (done bool, err error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return true, nil
+		}
+		return true, errUsage
+	}
+	return false, nil
+}
+
+// inputFlags adds the flag selecting the input format
+func inputFlags(fs *flag.FlagSet) *string {
+	return fs.String("i", "auto", "input `format`: auto, json or text")
+}
+
+// outputFlags adds the flag selecting the output format
+func outputFlags(fs *flag.FlagSet, def string) *string {
+	return fs.String("o", def, "output `format`: json, text, logfmt or ndjson")
+}
+
+// parseInputFormat converts the -i flag to an ingest format
+func parseInputFormat(name string) (ingest.Format, error) {
+	switch name {
+	case "auto":
+		return ingest.FormatAuto, nil
+	case "json":
+		return ingest.FormatJSON, nil
+	case "text":
+		return ingest.FormatText, nil
+	default:
+		return 0, fmt.Errorf("unknown input format %q", name)
+	}
+}
+
+// openInput opens a file argument, where "-" is standard input
+func openInput(env *environment, name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(env.stdin), nil
+	}
+	return os.Open(name)
+}
+
+// readRecords calls fn for each record in the files, or in standard input when no
+// files are given. Lines that cannot be parsed are reported on stderr and skipped.
+func readRecords(ctx context.Context, env *environment, files []string, format ingest.Format, fn func(*storage.Record) error) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, name := range files {
+		f, err := openInput(env, name)
+		if err != nil {
+			return err
+		}
+
+		err = readFrom(ctx, env, name, f, format, fn)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFrom calls fn for each record read from r
+func readFrom(ctx context.Context, env *environment, name string, r io.Reader, format ingest.Format, fn func(*storage.Record) error) error {
+	src := &errReader{r: r}
+	reader := ingest.NewReader(src, format)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if src.err != nil {
+			return src.err
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(env.stderr, "loglater: %s: %v\n", name, err)
+			continue
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// errReader records read errors, to tell them apart from lines that fail to parse
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+// parseTimeArg parses an RFC 3339 time, or a duration before now such as "10m"
+func parseTimeArg(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or a duration such as 10m", s)
+	}
+	return time.Now().Add(-d), nil
+}
+
+func runCat(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "cat", "[file ...]")
+	in := inputFlags(fs)
+	out := outputFlags(fs, "json")
+	if done, err := parseFlags(fs, args); done {
+		return err
+	}
+	return copyRecords(ctx, env, fs.Args(), *in, *out, nil)
+}
+
+func runConvert(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "convert", "-o format [file ...]")
+	in := inputFlags(fs)
+	out := outputFlags(fs, "")
+	if done, err := parseFlags(fs, args); done {
+		return err
+	}
+	if *out == "" {
+		fs.Usage()
+		return errUsage
+	}
+	return copyRecords(ctx, env, fs.Args(), *in, *out, nil)
+}
+
+func runFilter(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "filter", "[file ...]")
+	in := inputFlags(fs)
+	out := outputFlags(fs, "json")
+	level := fs.String("level", "", "minimum `level`, such as WARN or INFO+2")
+	since := fs.String("since", "", "only records at or after `time` (RFC 3339, or a duration before now such as 10m)")
+	until := fs.String("until", "", "only records before `time` (RFC 3339, or a duration before now)")
+	expr := fs.String("q", "", "query `expression`, such as 'api.user == \"123\" && msg ~ \"timeout\"'")
+	if done, err := parseFlags(fs, args); done {
+		return err
+	}
+
+	var filters []func(*storage.Record) bool
+	if *level != "" {
+		var min slog.Level
+		if err := min.UnmarshalText([]byte(*level)); err != nil {
+			return fmt.Errorf("invalid level %q", *level)
+		}
+		filters = append(filters, func(r *storage.Record) bool { return r.Level >= min })
+	}
+	if *since != "" {
+		t, err := parseTimeArg(*since)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, func(r *storage.Record) bool { return !r.Time.Before(t) })
+	}
+	if *until != "" {
+		t, err := parseTimeArg(*until)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, func(r *storage.Record) bool { return r.Time.Before(t) })
+	}
+	if *expr != "" {
+		e, err := query.Compile(*expr)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, e.Match)
+	}
+
+	return copyRecords(ctx, env, fs.Args(), *in, *out, func(r *storage.Record) bool {
+		for _, f := range filters {
+			if !f(r) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// copyRecords writes the records from the files that pass the filter
+func copyRecords(ctx context.Context, env *environment, files []string, inFormat, outFormat string, filter func(*storage.Record) bool) error {
+	format, err := parseInputFormat(inFormat)
+	if err != nil {
+		return err
+	}
+	out, err := newOutput(outFormat, env.stdout)
+	if err != nil {
+		return err
+	}
+
+	err = readRecords(ctx, env, files, format, func(r *storage.Record) error {
+		if filter != nil && !filter(r) {
+			return nil
+		}
+		return out.write(ctx, r)
+	})
+	return errors.Join(err, out.flush())
+}
+
+func runMerge(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "merge", "file ...")
+	in := inputFlags(fs)
+	out := outputFlags(fs, "json")
+	if done, err := parseFlags(fs, args); done {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	format, err := parseInputFormat(*in)
+	if err != nil {
+		return err
+	}
+	output, err := newOutput(*out, env.stdout)
+	if err != nil {
+		return err
+	}
+
+	var records []storage.Record
+	err = readRecords(ctx, env, fs.Args(), format, func(r *storage.Record) error {
+		records = append(records, *r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// A stable sort keeps the file order for records with the same time
+	slices.SortStableFunc(records, func(a, b storage.Record) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	for i := range records {
+		if err := output.write(ctx, &records[i]); err != nil {
+			return err
+		}
+	}
+	return output.flush()
+}
+
+func runStats(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "stats", "[file ...]")
+	in := inputFlags(fs)
+	by := fs.String("by", "", "count records by the attribute at `path`, such as component")
+	bucket := fs.Duration("bucket", 0, "histogram bucket `width`, such as 1m")
+	if done, err := parseFlags(fs, args); done {
+		return err
+	}
+
+	if *bucket < 0 {
+		return fmt.Errorf("invalid bucket width %s", *bucket)
+	}
+	format, err := parseInputFormat(*in)
+	if err != nil {
+		return err
+	}
+
+	var records []storage.Record
+	err = readRecords(ctx, env, fs.Args(), format, func(r *storage.Record) error {
+		records = append(records, *r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var opts []storage.StatsOption
+	if *by != "" {
+		opts = append(opts, storage.WithStatsAttr(*by))
+	}
+	if *bucket > 0 {
+		opts = append(opts, storage.WithStatsBucket(*bucket))
+	}
+	stats := storage.ComputeStats(records, opts...)
+	writeStats(env.stdout, stats, *by)
+	if stats.Bucket > *bucket {
+		_, _ = fmt.Fprintf(env.stderr, "loglater: bucket widened to %s to stay within %d buckets\n", stats.Bucket, storage.MaxBuckets)
+	}
+	return nil
+}
+
+// writeStats prints a stats summary as aligned text
+func writeStats(w io.Writer, stats storage.Stats, by string) {
+	p := func(format string, args ...any) { _, _ = fmt.Fprintf(w, format, args...) }
+
+	p("records: %d\n", stats.Total)
+	if !stats.First.IsZero() {
+		p("first:   %s\n", stats.First.Format(time.RFC3339Nano))
+		p("last:    %s\n", stats.Last.Format(time.RFC3339Nano))
+	}
+
+	p("\nby level:\n")
+	for _, level := range slices.Sorted(maps.Keys(stats.ByLevel)) {
+		p("  %-8s %d\n", level, stats.ByLevel[level])
+	}
+
+	p("\nby message:\n")
+	writeCounts(w, stats.ByMessage)
+
+	if by != "" {
+		p("\nby %s:\n", by)
+		writeCounts(w, stats.ByAttr)
+	}
+
+	if len(stats.Histogram) > 0 {
+		p("\nhistogram:\n")
+		for _, b := range stats.Histogram {
+			p("  %s %d\n", b.Start.Format(time.RFC3339), b.Count)
+		}
+	}
+}
+
+// writeCounts prints counts from the highest to the lowest, then by key
+func writeCounts(w io.Writer, counts map[string]int) {
+	keys := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(keys, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "  %6d %s\n", counts[key], key)
+	}
+}
@@ -0,0 +1,96 @@
+// Command loglater inspects and converts NDJSON dumps of collected log records, as
+// produced by replaying a collector into slog.NewJSONHandler. Files written by
+// slog.NewTextHandler are read too.
+//
+// Usage:
+//
+//	loglater cat     [-o format] [file ...]
+//	loglater tail    [-n lines] [-f] [-o format] file
+//	loglater filter  [-level L] [-since T] [-until T] [-q expr] [-o format] [file ...]
+//	loglater convert -o format [file ...]
+//	loglater stats   [-by attr] [-bucket d] [file ...]
+//	loglater merge   [-o format] file ...
+//
+// Output formats are json (the default), text, logfmt and ndjson, the stable schema
+// of the export package. Without files, or for the file "-", records are read from
+// standard input.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `usage: loglater <command> [flags] [file ...]
+
+Commands:
+  cat      print records
+  tail     print the last records, and optionally follow the file
+  filter   print records matching a level, time range or query expression
+  convert  convert records to another format
+  stats    summarize records by level, message and attribute
+  merge    merge several files by timestamp
+
+Run 'loglater <command> -h' for the flags of a command.
+`
+
+// command runs one subcommand
+type command func(ctx context.Context, env *environment, args []string) error
+
+var commands = map[string]command{
+	"cat":     runCat,
+	"tail":    runTail,
+	"filter":  runFilter,
+	"convert": runConvert,
+	"stats":   runStats,
+	"merge":   runMerge,
+}
+
+// environment holds the standard streams, so commands can be tested
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// run executes the command line and returns the process exit code
+func run(ctx context.Context, args []string, env *environment) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		_, _ = fmt.Fprint(env.stderr, usage)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(env.stderr, "loglater: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := cmd(ctx, env, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		_, _ = fmt.Fprintf(env.stderr, "loglater %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], &environment{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	})
+	stop()
+	os.Exit(code)
+}
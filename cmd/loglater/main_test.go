@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+const sampleLogs = `{"time":"2025-01-02T10:00:00Z","level":"INFO","msg":"started","port":8080}
+{"time":"2025-01-02T10:00:05Z","level":"WARN","msg":"slow request","api":{"user":"123"},"elapsed":1.5}
+{"time":"2025-01-02T10:00:10Z","level":"ERROR","msg":"request failed","api":{"user":"456"},"err":"timeout"}
+{"time":"2025-01-02T10:00:15Z","level":"INFO","msg":"stopped"}
+`
+
+// writeFile writes content to a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runCommand runs the command line with the given stdin and returns the exit code
+// and output streams
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(t.Context(), args, &environment{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+	})
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("no arguments", func(t *testing.T) {
+		code, _, stderr := runCommand(t, "")
+		if code != 2 || !strings.Contains(stderr, "usage:") {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		code, _, stderr := runCommand(t, "", "help")
+		if code != 0 || !strings.Contains(stderr, "Commands:") {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		code, _, stderr := runCommand(t, "", "frobnicate")
+		if code != 2 || !strings.Contains(stderr, `unknown command "frobnicate"`) {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+
+	t.Run("bad flag", func(t *testing.T) {
+		code, _, stderr := runCommand(t, "", "cat", "-nope")
+		if code != 2 || !strings.Contains(stderr, "usage: loglater cat") {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+
+	t.Run("command help", func(t *testing.T) {
+		code, _, stderr := runCommand(t, "", "filter", "-h")
+		if code != 0 || !strings.Contains(stderr, "-level") {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		code, _, stderr := runCommand(t, "", "cat", filepath.Join(t.TempDir(), "missing.log"))
+		if code != 1 || !strings.Contains(stderr, "loglater cat:") {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+}
+
+func TestCat(t *testing.T) {
+	t.Run("stdin round trip", func(t *testing.T) {
+		code, stdout, stderr := runCommand(t, sampleLogs, "cat")
+		if code != 0 {
+			t.Fatalf("code = %d, stderr = %q", code, stderr)
+		}
+		if stdout != sampleLogs {
+			t.Errorf("got:\n%s\nwant:\n%s", stdout, sampleLogs)
+		}
+	})
+
+	t.Run("files in order", func(t *testing.T) {
+		a := writeFile(t, "a.log", sampleLogs)
+		code, stdout, _ := runCommand(t, "", "cat", a, a)
+		if code != 0 || stdout != sampleLogs+sampleLogs {
+			t.Errorf("code = %d, stdout = %q", code, stdout)
+		}
+	})
+
+	t.Run("bad lines are skipped", func(t *testing.T) {
+		input := "not a record\n" + sampleLogs
+		code, stdout, stderr := runCommand(t, input, "cat", "-i", "json")
+		if code != 0 || stdout != sampleLogs {
+			t.Errorf("code = %d, stdout = %q", code, stdout)
+		}
+		if !strings.Contains(stderr, "loglater: -: ingest: line 1:") {
+			t.Errorf("stderr = %q", stderr)
+		}
+	})
+
+	t.Run("unknown formats", func(t *testing.T) {
+		if code, _, stderr := runCommand(t, sampleLogs, "cat", "-o", "xml"); code != 1 || !strings.Contains(stderr, `unknown output format "xml"`) {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+		if code, _, stderr := runCommand(t, sampleLogs, "cat", "-i", "xml"); code != 1 || !strings.Contains(stderr, `unknown input format "xml"`) {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+}
+
+func TestConvert(t *testing.T) {
+	first := `{"time":"2025-01-02T10:00:05Z","level":"WARN","msg":"slow request","api":{"user":"123"},"elapsed":1.5}` + "\n"
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"text", "time=2025-01-02T10:00:05.000Z level=WARN msg=\"slow request\" api.user=123 elapsed=1.5\n"},
+		{"logfmt", "time=2025-01-02T10:00:05Z level=WARN msg=\"slow request\" api.user=123 elapsed=1.5\n"},
+		{"ndjson", `{"seq":0,"time":"2025-01-02T10:00:05Z","level":"WARN","msg":"slow request","attrs":{"api":{"user":"123"},"elapsed":1.5}}` + "\n"},
+		{"json", first},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, first, "convert", "-o", tc.format)
+			if code != 0 {
+				t.Fatalf("code = %d, stderr = %q", code, stderr)
+			}
+			if stdout != tc.want {
+				t.Errorf("got %q, want %q", stdout, tc.want)
+			}
+		})
+	}
+
+	t.Run("text back to json", func(t *testing.T) {
+		// Text output does not keep value types, so the numeric user IDs come back as numbers
+		want := strings.NewReplacer(`"user":"123"`, `"user":123`, `"user":"456"`, `"user":456`).Replace(sampleLogs)
+		_, text, _ := runCommand(t, sampleLogs, "convert", "-o", "text")
+		code, stdout, stderr := runCommand(t, text, "convert", "-o", "json")
+		if code != 0 || stdout != want {
+			t.Errorf("code = %d, stderr = %q, got:\n%s", code, stderr, stdout)
+		}
+	})
+
+	t.Run("requires output format", func(t *testing.T) {
+		if code, _, _ := runCommand(t, first, "convert"); code != 2 {
+			t.Errorf("code = %d, want 2", code)
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"level", []string{"-level", "WARN"}, []string{"slow request", "request failed"}},
+		{"since", []string{"-since", "2025-01-02T10:00:10Z"}, []string{"request failed", "stopped"}},
+		{"until", []string{"-until", "2025-01-02T10:00:10Z"}, []string{"started", "slow request"}},
+		{"query", []string{"-q", `api.user == "123"`}, []string{"slow request"}},
+		{"combined", []string{"-level", "INFO", "-q", `msg ~ "request"`, "-since", "2025-01-02T10:00:06Z"}, []string{"request failed"}},
+		{"relative since", []string{"-since", "1h"}, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, sampleLogs, append([]string{"filter", "-o", "logfmt"}, tc.args...)...)
+			if code != 0 {
+				t.Fatalf("code = %d, stderr = %q", code, stderr)
+			}
+			lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+			if stdout == "" {
+				lines = nil
+			}
+			if len(lines) != len(tc.want) {
+				t.Fatalf("got %d records, want %d:\n%s", len(lines), len(tc.want), stdout)
+			}
+			for i, msg := range tc.want {
+				if !strings.Contains(lines[i], msg) {
+					t.Errorf("record %d = %q, want message %q", i, lines[i], msg)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-level", "LOUD"},
+			{"-since", "yesterday"},
+			{"-q", "msg =="},
+		} {
+			if code, _, _ := runCommand(t, sampleLogs, append([]string{"filter"}, args...)...); code != 1 {
+				t.Errorf("%v: code = %d, want 1", args, code)
+			}
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	a := writeFile(t, "a.log", `{"time":"2025-01-02T10:00:00Z","level":"INFO","msg":"a1"}
+{"time":"2025-01-02T10:00:10Z","level":"INFO","msg":"a2"}
+`)
+	b := writeFile(t, "b.log", `time=2025-01-02T10:00:05.000Z level=INFO msg=b1
+time=2025-01-02T10:00:10.000Z level=INFO msg=b2
+`)
+
+	code, stdout, stderr := runCommand(t, "", "merge", "-o", "text", a, b)
+	if code != 0 {
+		t.Fatalf("code = %d, stderr = %q", code, stderr)
+	}
+	want := `time=2025-01-02T10:00:00.000Z level=INFO msg=a1
+time=2025-01-02T10:00:05.000Z level=INFO msg=b1
+time=2025-01-02T10:00:10.000Z level=INFO msg=a2
+time=2025-01-02T10:00:10.000Z level=INFO msg=b2
+`
+	if stdout != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+	}
+
+	if code, _, _ := runCommand(t, "", "merge"); code != 2 {
+		t.Errorf("merge without files: code = %d, want 2", code)
+	}
+}
+
+func TestStats(t *testing.T) {
+	code, stdout, stderr := runCommand(t, sampleLogs, "stats", "-by", "api.user", "-bucket", "10s")
+	if code != 0 {
+		t.Fatalf("code = %d, stderr = %q", code, stderr)
+	}
+
+	want := `records: 4
+first:   2025-01-02T10:00:00Z
+last:    2025-01-02T10:00:15Z
+
+by level:
+  INFO     2
+  WARN     1
+  ERROR    1
+
+by message:
+       1 request failed
+       1 slow request
+       1 started
+       1 stopped
+
+by api.user:
+       1 123
+       1 456
+
+histogram:
+  2025-01-02T10:00:00Z 2
+  2025-01-02T10:00:10Z 2
+`
+	if stdout != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+	}
+}
+
+func TestStatsBucket(t *testing.T) {
+	t.Run("widened", func(t *testing.T) {
+		code, stdout, stderr := runCommand(t, sampleLogs, "stats", "-bucket", "1ns")
+		if code != 0 {
+			t.Fatalf("code = %d, stderr = %q", code, stderr)
+		}
+		if !strings.Contains(stderr, "bucket widened") || strings.Count(stdout, "\n  2025-") > storage.MaxBuckets {
+			t.Errorf("expected a bounded histogram and a warning, stderr = %q", stderr)
+		}
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		if code, _, _ := runCommand(t, sampleLogs, "stats", "-bucket", "-1s"); code != 1 {
+			t.Errorf("code = %d, want 1", code)
+		}
+	})
+}
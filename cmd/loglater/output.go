@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/robbyt/go-loglater/export"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// output writes records in one of the supported formats
+type output struct {
+	bw    *bufio.Writer
+	write func(ctx context.Context, r *storage.Record) error
+}
+
+// newOutput creates an output for the named format
+func newOutput(format string, w io.Writer) (*output, error) {
+	out := &output{bw: bufio.NewWriter(w)}
+
+	switch format {
+	case "json":
+		out.write = handlerWriter(slog.NewJSONHandler(out.bw, nil))
+	case "text":
+		out.write = handlerWriter(slog.NewTextHandler(out.bw, nil))
+	case "logfmt":
+		out.write = func(_ context.Context, r *storage.Record) error {
+			return export.WriteLogfmt(out.bw, slices.Values([]storage.Record{*r}))
+		}
+	case "ndjson":
+		out.write = func(_ context.Context, r *storage.Record) error {
+			return export.WriteNDJSON(out.bw, slices.Values([]storage.Record{*r}))
+		}
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return out, nil
+}
+
+// handlerWriter replays records through an slog handler
+func handlerWriter(h slog.Handler) func(context.Context, *storage.Record) error {
+	return func(ctx context.Context, r *storage.Record) error {
+		record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		record.AddAttrs(r.Attrs...)
+		return h.Handle(ctx, record)
+	}
+}
+
+// flush writes any buffered output
+func (o *output) flush() error {
+	return o.bw.Flush()
+}
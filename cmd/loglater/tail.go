@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/robbyt/go-loglater/ingest"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func runTail(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "tail", "file")
+	in := inputFlags(fs)
+	out := outputFlags(fs, "json")
+	n := fs.Int("n", 10, "print the last `count` records")
+	follow := fs.Bool("f", false, "keep printing records as they are appended to the file")
+	interval := fs.Duration("interval", 250*time.Millisecond, "how often to check the file for new records with -f")
+	if done, err := parseFlags(fs, args); done {
+		return err
+	}
+	if fs.NArg() != 1 || *n < 0 || *interval <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	format, err := parseInputFormat(*in)
+	if err != nil {
+		return err
+	}
+	output, err := newOutput(*out, env.stdout)
+	if err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	if name == "-" {
+		if *follow {
+			return errors.New("cannot follow standard input")
+		}
+		return tailReader(ctx, env, name, env.stdin, format, *n, output)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if !*follow {
+		return tailReader(ctx, env, name, f, format, *n, output)
+	}
+
+	t := &tailer{env: env, name: name, file: f, format: format, out: output}
+	return t.follow(ctx, *n, *interval)
+}
+
+// tailReader prints the last n records read from r
+func tailReader(ctx context.Context, env *environment, name string, r io.Reader, format ingest.Format, n int, out *output) error {
+	last := newRecordRing(n)
+	err := readFrom(ctx, env, name, r, format, func(r *storage.Record) error {
+		last.add(r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range last.records() {
+		if err := out.write(ctx, r); err != nil {
+			return err
+		}
+	}
+	return out.flush()
+}
+
+// recordRing keeps the most recent records up to a fixed count
+type recordRing struct {
+	buf  []*storage.Record
+	next int
+	full bool
+}
+
+func newRecordRing(n int) *recordRing {
+	return &recordRing{buf: make([]*storage.Record, n)}
+}
+
+func (r *recordRing) add(record *storage.Record) {
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = record
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// records returns the kept records, oldest first
+func (r *recordRing) records() []*storage.Record {
+	if !r.full {
+		return r.buf[:r.next]
+	}
+	return append(r.buf[r.next:len(r.buf):len(r.buf)], r.buf[:r.next]...)
+}
+
+// tailer follows a file as records are appended to it. Only complete lines are
+// parsed, so a record being written is not read until its newline arrives. When
+// the file shrinks it is assumed to have been truncated and is read from the start.
+type tailer struct {
+	env     *environment
+	name    string
+	file    *os.File
+	format  ingest.Format
+	out     *output
+	offset  int64
+	line    int
+	partial []byte
+}
+
+// follow prints the last n records, then new records until the context is done
+func (t *tailer) follow(ctx context.Context, n int, interval time.Duration) error {
+	last := newRecordRing(n)
+	if err := t.read(func(r *storage.Record) { last.add(r) }); err != nil {
+		return err
+	}
+	for _, r := range last.records() {
+		if err := t.out.write(ctx, r); err != nil {
+			return err
+		}
+	}
+	if err := t.out.flush(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var writeErr error
+		err := t.read(func(r *storage.Record) {
+			if writeErr == nil {
+				writeErr = t.out.write(ctx, r)
+			}
+		})
+		if err := errors.Join(err, writeErr, t.out.flush()); err != nil {
+			return err
+		}
+	}
+}
+
+// read parses the complete lines appended to the file since the last read
+func (t *tailer) read(fn func(*storage.Record)) error {
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < t.offset {
+		_, _ = fmt.Fprintf(t.env.stderr, "loglater: %s: file truncated\n", t.name)
+		t.offset = 0
+		t.line = 0
+		t.partial = nil
+	}
+	if info.Size() == t.offset {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(t.file, t.offset, info.Size()-t.offset))
+	if err != nil {
+		return err
+	}
+	t.offset += int64(len(data))
+	data = append(t.partial, data...)
+
+	for {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		line := data[:end]
+		data = data[end+1:]
+		t.line++
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record, err := ingest.Parse(line, t.format)
+		if err != nil {
+			_, _ = fmt.Fprintf(t.env.stderr, "loglater: %s: line %d: %v\n", t.name, t.line, err)
+			continue
+		}
+		fn(record)
+	}
+
+	t.partial = bytes.Clone(data)
+	return nil
+}
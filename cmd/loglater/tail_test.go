@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that can be read while a command writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls until the buffer contains want
+func waitFor(t *testing.T, b *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(b.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q, got:\n%s", want, b.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := writeFile(t, "app.log", sampleLogs)
+
+	t.Run("last records", func(t *testing.T) {
+		code, stdout, stderr := runCommand(t, "", "tail", "-n", "2", "-o", "logfmt", path)
+		if code != 0 {
+			t.Fatalf("code = %d, stderr = %q", code, stderr)
+		}
+		lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[0], "request failed") || !strings.Contains(lines[1], "stopped") {
+			t.Errorf("got:\n%s", stdout)
+		}
+	})
+
+	t.Run("more than available", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, sampleLogs, "tail", "-n", "100", "-")
+		if code != 0 || stdout != sampleLogs {
+			t.Errorf("code = %d, got:\n%s", code, stdout)
+		}
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "tail", "-n", "0", path)
+		if code != 0 || stdout != "" {
+			t.Errorf("code = %d, got:\n%s", code, stdout)
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"tail"},
+			{"tail", path, path},
+			{"tail", "-n", "-1", path},
+		} {
+			if code, _, _ := runCommand(t, "", args...); code != 2 {
+				t.Errorf("%v: code = %d, want 2", args, code)
+			}
+		}
+		if code, _, stderr := runCommand(t, "", "tail", "-f", "-"); code != 1 || !strings.Contains(stderr, "cannot follow standard input") {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	})
+}
+
+func TestTailFollow(t *testing.T) {
+	path := writeFile(t, "app.log", sampleLogs)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"tail", "-f", "-n", "1", "-interval", "5ms", "-o", "logfmt", path}, &environment{
+			stdin:  strings.NewReader(""),
+			stdout: stdout,
+			stderr: stderr,
+		})
+	}()
+
+	waitFor(t, stdout, "msg=stopped")
+	if strings.Contains(stdout.String(), "request failed") {
+		t.Errorf("printed more than the last record:\n%s", stdout)
+	}
+
+	// A partial line is held back until its newline is written
+	appendFile(t, path, `{"time":"2025-01-02T10:00:20Z","level":"INFO","msg":"appended"`)
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(stdout.String(), "appended") {
+		t.Fatalf("printed a partial line:\n%s", stdout)
+	}
+	appendFile(t, path, "}\n")
+	waitFor(t, stdout, "msg=appended")
+
+	// Truncation restarts from the beginning of the file
+	if err := os.WriteFile(path, []byte(`{"time":"2025-01-02T11:00:00Z","level":"INFO","msg":"rotated"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, stdout, "msg=rotated")
+	waitFor(t, stderr, "file truncated")
+
+	cancel()
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Errorf("code = %d, stderr = %q", code, stderr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tail -f did not stop after cancellation")
+	}
+}
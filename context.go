@@ -0,0 +1,60 @@
+package loglater
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextExtractor returns the attributes to capture from the context passed to
+// Handle, such as a trace ID or request ID
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+// WithContextExtractor captures attributes from the context of each record when it
+// is handled, and stores them in the record's Context, apart from its attributes.
+// It can be given more than once; the attributes of each extractor are kept in order.
+//
+// The records returned by GetLogs, All and GetLogsFrom keep the attributes in
+// Context; storage.Record.MergeContext turns them into top-level attributes for code
+// that reads only Attrs or Find. The export writers include them under "context",
+// as does debughttp, whose attr and q filters match them at the top level. Replay
+// passes them to the handler only with WithContextAttrs or WithContextRestorer.
+//
+//	loglater.WithContextExtractor(func(ctx context.Context) []slog.Attr {
+//		span := trace.SpanContextFromContext(ctx)
+//		if !span.IsValid() {
+//			return nil
+//		}
+//		return []slog.Attr{slog.String("trace_id", span.TraceID().String())}
+//	})
+func WithContextExtractor(extract ContextExtractor) Option {
+	return func(lc *LogCollector) {
+		lc.extractors = append(lc.extractors, extract)
+	}
+}
+
+// WithContextAttrs replays the attributes captured by context extractors as
+// top-level attributes of each record
+func WithContextAttrs() ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.contextAttrs = true
+	}
+}
+
+// WithContextRestorer calls restore with the attributes captured by context
+// extractors to build the context passed to the handler for each record, for
+// handlers that read values such as the trace from the context. It is not called for
+// records without captured attributes.
+func WithContextRestorer(restore func(ctx context.Context, attrs []slog.Attr) context.Context) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.restore = restore
+	}
+}
+
+// extractContext returns the attributes of every extractor for the context
+func (c *LogCollector) extractContext(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	for _, extract := range c.extractors {
+		attrs = append(attrs, extract(ctx)...)
+	}
+	return attrs
+}
@@ -0,0 +1,105 @@
+package loglater
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type traceKey struct{}
+
+// traceExtractor captures the trace ID stored in the context, if any
+func traceExtractor(ctx context.Context) []slog.Attr {
+	if id, ok := ctx.Value(traceKey{}).(string); ok {
+		return []slog.Attr{slog.String("trace_id", id)}
+	}
+	return nil
+}
+
+// traceHandler records the trace ID found in the context of each handled record
+type traceHandler struct {
+	traces *[]string
+}
+
+func (h traceHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	id, _ := ctx.Value(traceKey{}).(string)
+	*h.traces = append(*h.traces, id)
+	return nil
+}
+func (h traceHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h traceHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestContextExtractor(t *testing.T) {
+	setup := func() *LogCollector {
+		collector := NewLogCollector(nil,
+			WithContextExtractor(traceExtractor),
+			WithContextExtractor(func(context.Context) []slog.Attr {
+				return []slog.Attr{slog.String("tenant", "acme")}
+			}),
+		)
+		logger := slog.New(collector)
+		ctx := context.WithValue(t.Context(), traceKey{}, "abc123")
+		logger.WithGroup("api").InfoContext(ctx, "request", "user", "bob")
+		logger.InfoContext(t.Context(), "background")
+		return collector
+	}
+
+	t.Run("CapturesApartFromAttrs", func(t *testing.T) {
+		logs := setup().GetLogs()
+		if len(logs) != 2 {
+			t.Fatalf("Expected 2 records, got %d", len(logs))
+		}
+		ctxAttrs := logs[0].Context
+		if len(ctxAttrs) != 2 || ctxAttrs[0].String() != "trace_id=abc123" || ctxAttrs[1].String() != "tenant=acme" {
+			t.Errorf("Expected trace_id and tenant captured in order, got %v", ctxAttrs)
+		}
+		if _, ok := logs[0].Find("trace_id"); ok {
+			t.Error("Expected context attributes to be kept apart from the record attributes")
+		}
+		if len(logs[1].Context) != 1 {
+			t.Errorf("Expected only the tenant without a trace, got %v", logs[1].Context)
+		}
+	})
+
+	t.Run("NotReplayedByDefault", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := setup().PlayLogs(slog.NewTextHandler(&buf, nil)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if strings.Contains(buf.String(), "trace_id") {
+			t.Errorf("Expected no context attributes, got %s", buf.String())
+		}
+	})
+
+	t.Run("ReplayAsAttrs", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := setup().PlayLogs(slog.NewTextHandler(&buf, nil), WithContextAttrs()); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "trace_id=abc123 tenant=acme api.user=bob") {
+			t.Errorf("Expected top-level context attributes, got %s", buf.String())
+		}
+	})
+
+	t.Run("RestoreIntoContext", func(t *testing.T) {
+		restore := func(ctx context.Context, attrs []slog.Attr) context.Context {
+			for _, a := range attrs {
+				if a.Key == "trace_id" {
+					return context.WithValue(ctx, traceKey{}, a.Value.String())
+				}
+			}
+			return ctx
+		}
+
+		var traces []string
+		if err := setup().PlayLogs(traceHandler{&traces}, WithContextRestorer(restore)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if strings.Join(traces, ",") != "abc123," {
+			t.Errorf("Expected the trace restored for the first record only, got %q", traces)
+		}
+	})
+}
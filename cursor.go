@@ -0,0 +1,96 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// ErrNoSequence is returned by PlayFrom when the storage backend does not number the
+// records it stores, so there is no position to replay from.
+var ErrNoSequence = errors.New("storage does not assign sequence numbers")
+
+// Cursor marks a position in a collector's record sequence. It holds the sequence
+// number of the last record that was replayed; the zero Cursor is the position
+// before the first record.
+type Cursor uint64
+
+// StorageSinceReader returns the log records appended after a sequence number.
+// Storage backends may implement it to avoid copying records that a cursor has
+// already passed; otherwise records are filtered from GetAll.
+type StorageSinceReader interface {
+	GetSince(seq uint64) []storage.Record
+}
+
+// PlayFrom outputs the stored logs appended after the cursor to the provided handler,
+// and returns a cursor positioned after the last record that was handled successfully.
+//
+// On error, the returned cursor still marks the last successfully handled record, so
+// passing it to the next call resumes with the record that failed. Records skipped by
+// a filter count as handled. PlayFrom always replays sequentially, ignoring
+// WithParallel, so that the cursor is exact.
+func (c *LogCollector) PlayFrom(ctx context.Context, handler slog.Handler, cursor Cursor, opts ...ReplayOption) (Cursor, error) {
+	if handler == nil {
+		return cursor, errors.New("handler is nil")
+	}
+
+	cfg := newReplayConfig(opts)
+	records, err := c.recordsAfter(cursor)
+	if err != nil {
+		return cursor, err
+	}
+	for _, stored := range records {
+		if !cfg.match(&stored) {
+			cfg.report(len(records))
+			cursor = Cursor(stored.Seq)
+			continue
+		}
+		err := cfg.play(ctx, handler, &stored)
+		cfg.report(len(records))
+		if err != nil {
+			return cursor, err
+		}
+		cursor = Cursor(stored.Seq)
+	}
+	return cursor, nil
+}
+
+// GetLogsFrom returns the realized log records appended after the cursor. The Seq of
+// the last record, converted to a Cursor, continues from where they end. It returns
+// nothing if the storage backend does not number records.
+func (c *LogCollector) GetLogsFrom(cursor Cursor) []storage.Record {
+	records, _ := c.recordsAfter(cursor)
+	return realizeAll(records)
+}
+
+// GetRawLogsFrom returns the stored log records appended after the cursor, without
+// realizing them, for readers that go through many records and realize only those
+// they keep, with storage.Record.Realize. It returns nothing if the storage backend
+// does not number records.
+func (c *LogCollector) GetRawLogsFrom(cursor Cursor) []storage.Record {
+	records, _ := c.recordsAfter(cursor)
+	return records
+}
+
+// recordsAfter returns the raw records with a sequence number greater than the
+// cursor, or ErrNoSequence if the storage holds records without one.
+func (c *LogCollector) recordsAfter(cursor Cursor) ([]storage.Record, error) {
+	if sr, ok := c.store.(StorageSinceReader); ok {
+		return sr.GetSince(uint64(cursor)), nil
+	}
+
+	records := c.store.GetAll()
+	if len(records) > 0 && !slices.ContainsFunc(records, func(r storage.Record) bool { return r.Seq != 0 }) {
+		return nil, ErrNoSequence
+	}
+	result := records[:0]
+	for _, r := range records {
+		if r.Seq > uint64(cursor) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
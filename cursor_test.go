@@ -0,0 +1,262 @@
+package loglater
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestPlayFrom(t *testing.T) {
+	t.Run("OnlyNewRecords", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+
+		logger.Info("first")
+		logger.Info("second")
+
+		var buf bytes.Buffer
+		handler := slog.NewTextHandler(&buf, nil)
+
+		cursor, err := collector.PlayFrom(t.Context(), handler, 0)
+		if err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if cursor != 2 {
+			t.Errorf("Expected cursor 2, got %d", cursor)
+		}
+		if strings.Count(buf.String(), "\n") != 2 {
+			t.Errorf("Expected 2 lines, got: %s", buf.String())
+		}
+
+		logger.Info("third")
+		buf.Reset()
+
+		cursor, err = collector.PlayFrom(t.Context(), handler, cursor)
+		if err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if cursor != 3 {
+			t.Errorf("Expected cursor 3, got %d", cursor)
+		}
+		output := buf.String()
+		if strings.Count(output, "\n") != 1 || !strings.Contains(output, "third") {
+			t.Errorf("Expected only the third record, got: %s", output)
+		}
+	})
+
+	t.Run("NoNewRecords", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("only")
+
+		cursor, err := collector.PlayFrom(t.Context(), slog.NewTextHandler(&discardWriter{}, nil), 1)
+		if err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if cursor != 1 {
+			t.Errorf("Expected cursor to stay at 1, got %d", cursor)
+		}
+	})
+
+	t.Run("StopsAtFailure", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		for _, msg := range []string{"a", "b", "c"} {
+			logger.Info(msg)
+		}
+
+		handler := &failOnMessageHandler{fail: "b"}
+		cursor, err := collector.PlayFrom(t.Context(), handler, 0)
+		if !errors.Is(err, errHandlerFailed) {
+			t.Fatalf("Expected errHandlerFailed, got %v", err)
+		}
+		if cursor != 1 {
+			t.Errorf("Expected cursor 1 after failure, got %d", cursor)
+		}
+
+		// Retry resumes at the failed record
+		handler.fail = ""
+		cursor, err = collector.PlayFrom(t.Context(), handler, cursor)
+		if err != nil {
+			t.Fatalf("PlayFrom retry failed: %v", err)
+		}
+		if cursor != 3 {
+			t.Errorf("Expected cursor 3, got %d", cursor)
+		}
+		if got := strings.Join(handler.handled, ","); got != "a,b,c" {
+			t.Errorf("Expected a,b,c handled, got %s", got)
+		}
+	})
+
+	t.Run("NilHandler", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		cursor, err := collector.PlayFrom(t.Context(), nil, 5)
+		if err == nil {
+			t.Error("Expected error for nil handler")
+		}
+		if cursor != 5 {
+			t.Errorf("Expected cursor to be returned unchanged, got %d", cursor)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("test")
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		cursor, err := collector.PlayFrom(ctx, slog.NewTextHandler(&discardWriter{}, nil), 0)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if cursor != 0 {
+			t.Errorf("Expected cursor 0, got %d", cursor)
+		}
+	})
+
+	t.Run("AfterCleanup", func(t *testing.T) {
+		store := storage.NewRecordStorage(storage.WithMaxSize(2))
+		collector := NewLogCollector(nil, WithStorage(store))
+		logger := slog.New(collector)
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			logger.Info(msg)
+		}
+
+		handler := &failOnMessageHandler{}
+		cursor, err := collector.PlayFrom(t.Context(), handler, 1)
+		if err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if cursor != 4 {
+			t.Errorf("Expected cursor 4, got %d", cursor)
+		}
+		if got := strings.Join(handler.handled, ","); got != "c,d" {
+			t.Errorf("Expected c,d handled, got %s", got)
+		}
+	})
+
+	t.Run("StorageWithoutGetSince", func(t *testing.T) {
+		collector := NewLogCollector(nil, WithStorage(&sliceStorage{}))
+		logger := slog.New(collector)
+		logger.Info("a")
+		logger.Info("b")
+
+		handler := &failOnMessageHandler{}
+		cursor, err := collector.PlayFrom(t.Context(), handler, 1)
+		if err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if cursor != 2 {
+			t.Errorf("Expected cursor 2, got %d", cursor)
+		}
+		if got := strings.Join(handler.handled, ","); got != "b" {
+			t.Errorf("Expected only b handled, got %s", got)
+		}
+	})
+}
+
+func TestPlayFromUnnumberedStorage(t *testing.T) {
+	collector := NewLogCollector(nil, WithStorage(&unnumberedStorage{}))
+	slog.New(collector).Info("a")
+
+	cursor, err := collector.PlayFrom(t.Context(), &failOnMessageHandler{}, 0)
+	if !errors.Is(err, ErrNoSequence) {
+		t.Errorf("Expected ErrNoSequence, got %v", err)
+	}
+	if cursor != 0 {
+		t.Errorf("Expected the cursor unchanged, got %d", cursor)
+	}
+}
+
+func TestGetLogsFrom(t *testing.T) {
+	collector := NewLogCollector(nil)
+	logger := slog.New(collector).WithGroup("req")
+	logger.Info("first", "id", 1)
+	logger.Info("second", "id", 2)
+	logger.Info("third", "id", 3)
+
+	records := collector.GetLogsFrom(1)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Message != "second" || records[0].Seq != 2 || records[1].Seq != 3 {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+	if v, ok := records[0].Find("req", "id"); !ok || v.Int64() != 2 {
+		t.Errorf("Expected realized req.id=2, got %v (found %v)", v, ok)
+	}
+
+	if records := collector.GetLogsFrom(Cursor(records[1].Seq)); len(records) != 0 {
+		t.Errorf("Expected no records after the last one, got %d", len(records))
+	}
+}
+
+func TestGetRawLogsFrom(t *testing.T) {
+	collector := NewLogCollector(nil)
+	logger := slog.New(collector).WithGroup("req")
+	logger.Info("first", "id", 1)
+	logger.Info("second", "id", 2)
+
+	records := collector.GetRawLogsFrom(1)
+	if len(records) != 1 || records[0].Seq != 2 {
+		t.Fatalf("Expected the second record, got %+v", records)
+	}
+	if len(records[0].Journal) == 0 {
+		t.Error("Expected the record unrealized, with its journal")
+	}
+	if v, ok := records[0].FindRealized("req", "id"); !ok || v.Int64() != 2 {
+		t.Errorf("Expected req.id=2, got %v (found %v)", v, ok)
+	}
+}
+
+var errHandlerFailed = errors.New("handler failed")
+
+// failOnMessageHandler records handled messages and fails on a chosen message
+type failOnMessageHandler struct {
+	fail    string
+	handled []string
+}
+
+func (h *failOnMessageHandler) Enabled(ctx context.Context, level slog.Level) bool { return true }
+func (h *failOnMessageHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.fail != "" && r.Message == h.fail {
+		return errHandlerFailed
+	}
+	h.handled = append(h.handled, r.Message)
+	return nil
+}
+func (h *failOnMessageHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h *failOnMessageHandler) WithGroup(name string) slog.Handler       { return h }
+
+// sliceStorage is a minimal Storage that numbers records but has no GetSince
+type sliceStorage struct {
+	records []storage.Record
+}
+
+func (s *sliceStorage) Append(record *storage.Record) {
+	stored := *record
+	stored.Seq = uint64(len(s.records) + 1)
+	s.records = append(s.records, stored)
+}
+
+func (s *sliceStorage) GetAll() []storage.Record {
+	return append([]storage.Record(nil), s.records...)
+}
+
+// unnumberedStorage is a Storage that does not assign sequence numbers
+type unnumberedStorage struct {
+	records []storage.Record
+}
+
+func (s *unnumberedStorage) Append(record *storage.Record) {
+	s.records = append(s.records, *record)
+}
+
+func (s *unnumberedStorage) GetAll() []storage.Record {
+	return append([]storage.Record(nil), s.records...)
+}
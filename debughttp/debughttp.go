@@ -0,0 +1,251 @@
+// Package debughttp serves a collector's records over HTTP, for inspecting the logs
+// of a running process without restarting it at a more verbose level:
+//
+//	mux.Handle("/debug/logs/", debughttp.New(collector))
+//
+// The handler serves a minimal HTML page at the mount point, a JSON listing of records
+// at records, and a Server-Sent Events stream of new records at tail. Records use the
+// schema of export.WriteNDJSON. Both endpoints accept the same filter parameters:
+//
+//	level   minimum level, such as WARN or DEBUG+2
+//	since   records at or after a time: RFC 3339, or a duration before now such as 10m
+//	until   records before a time, in the same forms as since
+//	attr    path=value, matching attributes by their text, such as api.user=123; repeatable
+//	q       a query expression, as accepted by query.Compile
+//
+// The attr and q filters see the attributes captured by context extractors as
+// top-level attributes, such as trace_id.
+//
+// The listing pages through records in sequence order: after is the cursor to start
+// from and limit the page size. The response holds the records, the cursor for the
+// next page in next, and whether more records follow in more:
+//
+//	GET /debug/logs/records?level=WARN&since=10m&limit=50
+//	{"records":[...],"next":1042,"more":false}
+//
+// With last=N, the listing instead returns the last N matching records.
+//
+// The tail stream sends one message per record, with the record's sequence number as
+// the event ID, so browsers resume where they stopped after a reconnect. A stream
+// starts after the cursor in after, or at the end of the log; last=N replays the
+// last N matching records first.
+//
+// The handler exposes everything the collector captured, so mount it only where the
+// pprof endpoints would be acceptable.
+package debughttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/export"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+const (
+	defaultLimit        = 100
+	defaultMaxLimit     = 1000
+	defaultPollInterval = time.Second
+)
+
+// Handler serves the records of a collector
+type Handler struct {
+	collector    *loglater.LogCollector
+	maxLimit     int
+	pollInterval time.Duration
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithMaxLimit caps the number of records returned by one listing request. The
+// default is 1000.
+func WithMaxLimit(n int) Option {
+	return func(h *Handler) {
+		if n > 0 {
+			h.maxLimit = n
+		}
+	}
+}
+
+// WithPollInterval sets how often the tail stream checks for new records. The
+// default is one second.
+func WithPollInterval(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.pollInterval = d
+		}
+	}
+}
+
+// New creates a handler serving the collector's records
+func New(collector *loglater.LogCollector, opts ...Option) *Handler {
+	h := &Handler{
+		collector:    collector,
+		maxLimit:     defaultMaxLimit,
+		pollInterval: defaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP routes requests by the last element of the path: records and tail serve
+// the listing and the stream, and anything else serves the page.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch path.Base(r.URL.Path) {
+	case "records":
+		h.serveRecords(w, r)
+	case "tail":
+		h.serveTail(w, r)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte(page))
+	}
+}
+
+// listing is the JSON response of the records endpoint
+type listing struct {
+	Records []json.RawMessage `json:"records"`
+	Next    loglater.Cursor   `json:"next"`
+	More    bool              `json:"more"`
+}
+
+// serveRecords writes one page of matching records as JSON
+func (h *Handler) serveRecords(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	match, err := parseFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, err := parseCursor(query.Get("after"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseCount(query, "limit", defaultLimit)
+	if err == nil && limit < 1 {
+		err = badParam("limit", query.Get("limit"))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	last, err := parseCount(query, "last", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit = min(limit, h.maxLimit)
+	last = min(last, h.maxLimit)
+
+	// Filter the stored records, so that only the records written are realized
+	records := h.collector.GetRawLogsFrom(after)
+	result := listing{Next: after}
+	if len(records) > 0 {
+		result.Next = loglater.Cursor(records[len(records)-1].Seq)
+	}
+
+	var selected []storage.Record
+	if query.Has("last") {
+		selected = lastMatching(records, match, last)
+	} else {
+		for i := range records {
+			if !match(&records[i]) {
+				continue
+			}
+			if len(selected) == limit {
+				result.More = true
+				result.Next = loglater.Cursor(selected[len(selected)-1].Seq)
+				break
+			}
+			selected = append(selected, records[i])
+		}
+	}
+
+	result.Records, err = encodeRecords(selected)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// lastMatching returns the last n records that match, in order
+func lastMatching(records []storage.Record, match func(*storage.Record) bool, n int) []storage.Record {
+	var result []storage.Record
+	for i := len(records) - 1; i >= 0 && len(result) < n; i-- {
+		if match(&records[i]) {
+			result = append(result, records[i])
+		}
+	}
+	slices.Reverse(result)
+	return result
+}
+
+// encodeRecords realizes each stored record and encodes it with the NDJSON export
+// schema
+func encodeRecords(records []storage.Record) ([]json.RawMessage, error) {
+	realized := func(yield func(storage.Record) bool) {
+		for i := range records {
+			if !yield(records[i].Realize()) {
+				return
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteNDJSON(&buf, realized); err != nil {
+		return nil, err
+	}
+
+	result := make([]json.RawMessage, 0, len(records))
+	for line := range bytes.Lines(buf.Bytes()) {
+		result = append(result, bytes.TrimSuffix(line, []byte("\n")))
+	}
+	return result, nil
+}
+
+// parseCursor parses a cursor parameter, where empty means the start of the log
+func parseCursor(s string) (loglater.Cursor, error) {
+	if s == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, badParam("after", s)
+	}
+	return loglater.Cursor(seq), nil
+}
+
+// parseCount parses a non-negative count parameter
+func parseCount(query url.Values, name string, def int) (int, error) {
+	values := query[name]
+	if len(values) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(values[0])
+	if err != nil || n < 0 {
+		return 0, badParam(name, values[0])
+	}
+	return n, nil
+}
@@ -0,0 +1,203 @@
+package debughttp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater"
+)
+
+// testRecord is the subset of the export schema checked by the tests
+type testRecord struct {
+	Seq   uint64         `json:"seq"`
+	Level string         `json:"level"`
+	Msg   string         `json:"msg"`
+	Attrs map[string]any `json:"attrs"`
+}
+
+type testListing struct {
+	Records []testRecord `json:"records"`
+	Next    uint64       `json:"next"`
+	More    bool         `json:"more"`
+}
+
+// newTestCollector returns a collector holding records 1 to 6
+func newTestCollector() *loglater.LogCollector {
+	collector := loglater.NewLogCollector(nil)
+	logger := slog.New(collector)
+	api := logger.WithGroup("api")
+
+	logger.Debug("cache miss", "key", "a")
+	api.Info("request", "user", "123")
+	api.Warn("slow request", "user", "456")
+	logger.Info("tick")
+	api.Error("request failed", "user", "123")
+	logger.Debug("cache miss", "key", "b")
+	return collector
+}
+
+// get serves a request and returns the recorded response
+func get(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+// getListing requests a listing and decodes it
+func getListing(t *testing.T, h http.Handler, target string) testListing {
+	t.Helper()
+	rec := get(t, h, target)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var result testListing
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	return result
+}
+
+// seqs returns the sequence numbers of the records
+func seqs(records []testRecord) []uint64 {
+	result := make([]uint64, 0, len(records))
+	for _, r := range records {
+		result = append(result, r.Seq)
+	}
+	return result
+}
+
+func TestRecords(t *testing.T) {
+	h := New(newTestCollector())
+
+	t.Run("all", func(t *testing.T) {
+		result := getListing(t, h, "/debug/logs/records")
+		if got := seqs(result.Records); len(got) != 6 {
+			t.Fatalf("got records %v", got)
+		}
+		if result.Next != 6 || result.More {
+			t.Errorf("next = %d, more = %v", result.Next, result.More)
+		}
+
+		first := result.Records[1]
+		if first.Level != "INFO" || first.Msg != "request" {
+			t.Errorf("unexpected record %+v", first)
+		}
+		if api, ok := first.Attrs["api"].(map[string]any); !ok || api["user"] != "123" {
+			t.Errorf("unexpected attrs %v", first.Attrs)
+		}
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		var got []uint64
+		var pages int
+		after := "0"
+		for {
+			result := getListing(t, h, "/debug/logs/records?limit=2&level=INFO&after="+after)
+			got = append(got, seqs(result.Records)...)
+			pages++
+			if !result.More {
+				if result.Next != 6 {
+					t.Errorf("final next = %d, want 6", result.Next)
+				}
+				break
+			}
+			after = strconv.FormatUint(result.Next, 10)
+		}
+		if want := []uint64{2, 3, 4, 5}; !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if pages != 2 {
+			t.Errorf("got %d pages, want 2", pages)
+		}
+	})
+
+	t.Run("empty page after the end", func(t *testing.T) {
+		result := getListing(t, h, "/debug/logs/records?after=6")
+		if len(result.Records) != 0 || result.Next != 6 || result.More {
+			t.Errorf("got %+v", result)
+		}
+		if body := get(t, h, "/debug/logs/records?after=6").Body.String(); !strings.Contains(body, `"records":[]`) {
+			t.Errorf("expected an empty records array, got %s", body)
+		}
+	})
+
+	t.Run("last", func(t *testing.T) {
+		result := getListing(t, h, "/debug/logs/records?last=2&attr=api.user=123")
+		if got := seqs(result.Records); !slices.Equal(got, []uint64{2, 5}) {
+			t.Errorf("got %v", got)
+		}
+
+		result = getListing(t, h, "/debug/logs/records?last=2")
+		if got := seqs(result.Records); !slices.Equal(got, []uint64{5, 6}) {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("max limit", func(t *testing.T) {
+		h := New(newTestCollector(), WithMaxLimit(3))
+		result := getListing(t, h, "/debug/logs/records?limit=100")
+		if got := seqs(result.Records); !slices.Equal(got, []uint64{1, 2, 3}) || !result.More || result.Next != 3 {
+			t.Errorf("got %v, next %d, more %v", got, result.Next, result.More)
+		}
+	})
+
+	t.Run("bad parameters", func(t *testing.T) {
+		for _, target := range []string{
+			"/records?after=x",
+			"/records?limit=-1",
+			"/records?limit=0",
+			"/records?last=many",
+			"/records?level=LOUD",
+			"/records?since=yesterday",
+			"/records?until=tomorrow",
+			"/records?attr=novalue",
+			"/records?q=msg+==",
+		} {
+			if rec := get(t, h, target); rec.Code != http.StatusBadRequest {
+				t.Errorf("GET %s: status %d, want 400", target, rec.Code)
+			}
+		}
+	})
+}
+
+func TestServeHTTP(t *testing.T) {
+	h := New(newTestCollector())
+
+	t.Run("page", func(t *testing.T) {
+		rec := get(t, h, "/debug/logs/")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Fatalf("status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if body := rec.Body.String(); !strings.Contains(body, `fetch("records?"`) || !strings.Contains(body, `EventSource("tail?"`) {
+			t.Errorf("page does not use the relative endpoints")
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/logs/records", nil))
+		if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+			t.Errorf("status %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+		}
+	})
+
+	t.Run("mounted on a mux", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/logs/", h)
+
+		result := getListing(t, mux, "/debug/logs/records?q=level+>=+WARN")
+		if got := seqs(result.Records); !slices.Equal(got, []uint64{3, 5}) {
+			t.Errorf("got %v", got)
+		}
+	})
+}
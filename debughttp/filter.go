@@ -0,0 +1,96 @@
+package debughttp
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/robbyt/go-loglater/query"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// badParam reports an invalid query parameter
+func badParam(name, value string) error {
+	return fmt.Errorf("invalid %s parameter %q", name, value)
+}
+
+// parseFilter builds a filter of stored records from the level, since, until, attr
+// and q parameters. Attributes are matched at their realized path, with the attributes
+// captured from the context at the top level, and records are realized only for a
+// query expression, once the other filters match. Without any of the parameters every
+// record matches.
+func parseFilter(params url.Values) (func(*storage.Record) bool, error) {
+	var filters []func(*storage.Record) bool
+
+	if s := params.Get("level"); s != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(s)); err != nil {
+			return nil, badParam("level", s)
+		}
+		filters = append(filters, func(r *storage.Record) bool { return r.Level >= level })
+	}
+
+	if s := params.Get("since"); s != "" {
+		t, err := parseTime(s)
+		if err != nil {
+			return nil, badParam("since", s)
+		}
+		filters = append(filters, func(r *storage.Record) bool { return !r.Time.Before(t) })
+	}
+
+	if s := params.Get("until"); s != "" {
+		t, err := parseTime(s)
+		if err != nil {
+			return nil, badParam("until", s)
+		}
+		filters = append(filters, func(r *storage.Record) bool { return r.Time.Before(t) })
+	}
+
+	for _, s := range params["attr"] {
+		path, value, ok := strings.Cut(s, "=")
+		if !ok || path == "" {
+			return nil, badParam("attr", s)
+		}
+		keys := strings.Split(path, ".")
+		filters = append(filters, func(r *storage.Record) bool {
+			merged := r.MergeContext()
+			v, found := merged.FindRealized(keys...)
+			return found && v.String() == value
+		})
+	}
+
+	if s := params.Get("q"); s != "" {
+		expr, err := query.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(r *storage.Record) bool {
+			merged := r.MergeContext()
+			realized := merged.Realize()
+			return expr.Match(&realized)
+		})
+	}
+
+	return func(r *storage.Record) bool {
+		for _, f := range filters {
+			if !f(r) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parseTime parses an RFC 3339 time, or a duration before now such as "10m"
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-d), nil
+}
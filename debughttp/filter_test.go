@@ -0,0 +1,96 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestParseFilter(t *testing.T) {
+	now := time.Now()
+	record := &storage.Record{
+		Time:    now.Add(-5 * time.Minute),
+		Level:   slog.LevelWarn,
+		Message: "slow request",
+		Attrs: []slog.Attr{
+			slog.Group("api", slog.Int("user", 123), slog.String("path", "/v1/items")),
+		},
+	}
+
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"level=WARN", true},
+		{"level=ERROR", false},
+		{"level=INFO%2B3", true},
+		{"since=10m", true},
+		{"since=1m", false},
+		{"until=1m", true},
+		{"until=10m", false},
+		{"since=" + url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)), true},
+		{"attr=api.user=123", true},
+		{"attr=api.user=456", false},
+		{"attr=api.path=/v1/items&attr=api.user=123", true},
+		{"attr=api.path=/v1/items&attr=api.user=456", false},
+		{"attr=user=123", false},
+		{"q=" + url.QueryEscape(`msg ~ "slow" && api.user > 100`), true},
+		{"q=" + url.QueryEscape(`msg == "fast"`), false},
+		{"level=DEBUG&attr=api.user=123&q=api.path", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			params, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			match, err := parseFilter(params)
+			if err != nil {
+				t.Fatalf("parseFilter failed: %v", err)
+			}
+			if got := match(record); got != tc.want {
+				t.Errorf("match = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("stored record", func(t *testing.T) {
+		// The attributes are in a group opened with WithGroup, and the trace ID
+		// was captured from the context
+		stored := &storage.Record{
+			Level:   slog.LevelWarn,
+			Journal: storage.OperationJournal{{Type: storage.OpGroup, Group: "api"}},
+			Attrs:   []slog.Attr{slog.Int("user", 123)},
+			Context: []slog.Attr{slog.String("trace_id", "abc")},
+		}
+		cases := []struct {
+			query string
+			want  bool
+		}{
+			{"attr=api.user=123", true},
+			{"attr=user=123", false},
+			{"q=" + url.QueryEscape(`api.user == 123`), true},
+			{"q=" + url.QueryEscape(`user == 123`), false},
+			{"attr=trace_id=abc", true},
+			{"q=" + url.QueryEscape(`trace_id == "abc" && api.user == 123`), true},
+		}
+		for _, tc := range cases {
+			params, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			match, err := parseFilter(params)
+			if err != nil {
+				t.Fatalf("parseFilter failed: %v", err)
+			}
+			if got := match(stored); got != tc.want {
+				t.Errorf("%s: match = %v, want %v", tc.query, got, tc.want)
+			}
+		}
+	})
+}
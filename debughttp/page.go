@@ -0,0 +1,95 @@
+package debughttp
+
+// page is the HTML page served at the mount point. It loads the last records with the
+// listing endpoint and follows new ones with the tail stream, using relative URLs so
+// the handler can be mounted anywhere.
+const page = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Logs</title>
+<style>
+body { font: 13px monospace; margin: 1em; }
+form { margin-bottom: 1em; }
+input { font: inherit; margin-right: 0.5em; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 1px 6px; vertical-align: top; white-space: pre-wrap; }
+tr:nth-child(even) { background: #f4f4f4; }
+.DEBUG { color: #777; } .WARN { color: #a60; } .ERROR { color: #c00; }
+#status { color: #777; }
+</style>
+</head>
+<body>
+<form id="filter">
+<input name="level" placeholder="level (DEBUG, WARN...)" size="20">
+<input name="since" placeholder="since (10m or RFC 3339)" size="22">
+<input name="q" placeholder='query, e.g. api.user == "123"' size="40">
+<input name="last" value="200" size="5" title="records to load">
+<button>Load</button>
+<label><input type="checkbox" id="follow" checked>follow</label>
+<span id="status"></span>
+</form>
+<table><tbody id="records"></tbody></table>
+<script>
+const form = document.getElementById("filter");
+const rows = document.getElementById("records");
+const status = document.getElementById("status");
+let stream = null;
+
+function params() {
+  const p = new URLSearchParams();
+  for (const [k, v] of new FormData(form)) {
+    if (v !== "") p.set(k, v);
+  }
+  return p;
+}
+
+function attrs(obj, prefix) {
+  return Object.entries(obj || {}).map(([k, v]) =>
+    v !== null && typeof v === "object" && !Array.isArray(v)
+      ? attrs(v, prefix + k + ".")
+      : prefix + k + "=" + JSON.stringify(v)
+  ).join(" ");
+}
+
+function add(rec) {
+  const tr = document.createElement("tr");
+  tr.className = rec.level.replace(/[+-].*/, "");
+  for (const text of [rec.seq, rec.time || "", rec.level, rec.msg, attrs(rec.attrs, "")]) {
+    const td = document.createElement("td");
+    td.textContent = text;
+    tr.appendChild(td);
+  }
+  rows.appendChild(tr);
+}
+
+async function load(event) {
+  if (event) event.preventDefault();
+  if (stream) stream.close();
+  rows.replaceChildren();
+  const p = params();
+  const res = await fetch("records?" + p);
+  if (!res.ok) {
+    status.textContent = await res.text();
+    return;
+  }
+  const body = await res.json();
+  body.records.forEach(add);
+  status.textContent = body.records.length + " records";
+  if (document.getElementById("follow").checked) {
+    p.delete("last");
+    p.set("after", body.next);
+    stream = new EventSource("tail?" + p);
+    stream.onmessage = (e) => { add(JSON.parse(e.data)); window.scrollTo(0, document.body.scrollHeight); };
+    stream.onerror = () => { status.textContent = "stream disconnected, retrying"; };
+    stream.onopen = () => { status.textContent = "following"; };
+  }
+}
+
+form.addEventListener("submit", load);
+document.getElementById("follow").addEventListener("change", () => load());
+load();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,105 @@
+package debughttp
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// serveTail streams matching records as Server-Sent Events until the client goes away
+func (h *Handler) serveTail(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	match, err := parseFilter(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	last, err := parseCount(params, "last", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A reconnecting browser sends the ID of the last event it received
+	start := r.Header.Get("Last-Event-ID")
+	if start == "" {
+		start = params.Get("after")
+	}
+
+	var cursor loglater.Cursor
+	var backlog []storage.Record
+	if start != "" {
+		if cursor, err = parseCursor(start); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		records := h.collector.GetRawLogsFrom(0)
+		if len(records) > 0 {
+			cursor = loglater.Cursor(records[len(records)-1].Seq)
+		}
+		backlog = lastMatching(records, match, min(last, h.maxLimit))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	if err := writeEvents(w, backlog); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		records := h.collector.GetRawLogsFrom(cursor)
+		if len(records) == 0 {
+			continue
+		}
+		cursor = loglater.Cursor(records[len(records)-1].Seq)
+
+		records = slices.DeleteFunc(records, func(r storage.Record) bool { return !match(&r) })
+		if len(records) == 0 {
+			continue
+		}
+		if err := writeEvents(w, records); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvents writes one event per record, with the record's sequence number as its ID
+func writeEvents(w http.ResponseWriter, records []storage.Record) error {
+	data, err := encodeRecords(records)
+	if err != nil {
+		return err
+	}
+	for i, d := range data {
+		if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", records[i].Seq, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
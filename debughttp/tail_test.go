@@ -0,0 +1,138 @@
+package debughttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// event is one Server-Sent Event
+type event struct {
+	id     string
+	record testRecord
+}
+
+// openTail starts a tail request and returns a channel of its events
+func openTail(t *testing.T, srv *httptest.Server, target string, header http.Header) <-chan event {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan event)
+	go func() {
+		defer close(events)
+		var ev event
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.record); err != nil {
+					t.Errorf("decoding event data %q: %v", line, err)
+				}
+			case line == "":
+				select {
+				case events <- ev:
+				case <-t.Context().Done():
+					return
+				}
+				ev = event{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent waits for the next event
+func nextEvent(t *testing.T, events <-chan event) event {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return event{}
+}
+
+func TestTail(t *testing.T) {
+	collector := newTestCollector()
+	logger := slog.New(collector)
+	srv := httptest.NewServer(New(collector, WithPollInterval(5*time.Millisecond)))
+	t.Cleanup(srv.Close)
+
+	t.Run("new records", func(t *testing.T) {
+		events := openTail(t, srv, "/debug/logs/tail?level=WARN", nil)
+
+		// The stream starts at the end of the log once its headers arrive
+		logger.Info("ignored")
+		logger.Warn("first warning")
+		logger.Error("an error", "code", 7)
+
+		ev := nextEvent(t, events)
+		if ev.record.Msg != "first warning" || ev.id != "8" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		ev = nextEvent(t, events)
+		if ev.record.Msg != "an error" || ev.record.Attrs["code"] != float64(7) {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	})
+
+	t.Run("backlog", func(t *testing.T) {
+		events := openTail(t, srv, "/debug/logs/tail?last=2&attr=api.user=123", nil)
+		for _, want := range []string{"request", "request failed"} {
+			if ev := nextEvent(t, events); ev.record.Msg != want {
+				t.Errorf("got %q, want %q", ev.record.Msg, want)
+			}
+		}
+	})
+
+	t.Run("after", func(t *testing.T) {
+		events := openTail(t, srv, "/debug/logs/tail?after=5", nil)
+		if ev := nextEvent(t, events); ev.record.Seq != 6 {
+			t.Errorf("got seq %d, want 6", ev.record.Seq)
+		}
+	})
+
+	t.Run("resume from last event id", func(t *testing.T) {
+		header := http.Header{"Last-Event-Id": {"8"}}
+		events := openTail(t, srv, "/debug/logs/tail?after=1", header)
+		if ev := nextEvent(t, events); ev.record.Seq != 9 {
+			t.Errorf("got seq %d, want 9", ev.record.Seq)
+		}
+	})
+
+	t.Run("bad parameters", func(t *testing.T) {
+		for _, target := range []string{"/tail?after=x", "/tail?last=-2", "/tail?q=("} {
+			rec := get(t, New(collector), target)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("GET %s: status %d, want 400", target, rec.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// ErrRemoveUnsupported is returned by Drain when the storage backend cannot remove records.
+var ErrRemoveUnsupported = errors.New("storage does not support removing records")
+
+// StorageRemover removes log records from a storage backend by sequence number
+type StorageRemover interface {
+	Remove(seqs ...uint64) int
+}
+
+// StorageOccurrenceRemover removes log records by sequence number, keeping the
+// occurrences that deduplicated records collapsed after they were read. Drain uses
+// it when the storage backend implements it, so that duplicates logged during a
+// Drain are not lost.
+type StorageOccurrenceRemover interface {
+	RemoveOccurrences(seen map[uint64]int) int
+}
+
+// StorageClaimer hands out records under the storage backend's own lock, marking
+// them claimed until they are released, so that records are not handed out twice
+// while they are being handled. Drain uses it when the storage backend implements
+// it, so that concurrent drains of any collectors sharing the store are safe.
+type StorageClaimer interface {
+	Claim() []storage.Record
+	Release(seqs ...uint64)
+}
+
+// Drain outputs the stored logs to the provided handler and removes each record that
+// was handled successfully, using the collector as an outbox.
+//
+// Replay stops at the first handler error; the failed record and all records after it
+// stay in storage for the next call. With WithParallel, replay continues past errors
+// and only the failed records stay. Records skipped by a filter are left in storage
+// untouched, as are occurrences that a deduplicating store collapses into a record
+// while it is being handled.
+//
+// Concurrent calls to Drain never hand out a record twice when the store implements
+// StorageClaimer, as the built-in stores do: each call claims the records it replays,
+// and records claimed by another call are left to it. With other stores, concurrent
+// calls are serialized only on collectors derived from the same root collector.
+func (c *LogCollector) Drain(ctx context.Context, handler slog.Handler, opts ...ReplayOption) error {
+	if handler == nil {
+		return errors.New("handler is nil")
+	}
+
+	remover, ok := c.store.(StorageRemover)
+	if !ok {
+		return ErrRemoveUnsupported
+	}
+
+	var records []storage.Record
+	if claimer, ok := c.store.(StorageClaimer); ok {
+		records = claimer.Claim()
+		defer claimer.Release(seqsOf(records)...)
+	} else {
+		c.drainMu.Lock()
+		defer c.drainMu.Unlock()
+		records = c.store.GetAll()
+	}
+
+	occurrences := make(map[uint64]int, len(records))
+	for i := range records {
+		occurrences[records[i].Seq] = records[i].Occurrences()
+	}
+
+	seen := make(map[uint64]int)
+	err := newReplayConfig(opts).replay(ctx, handler, records, func(seq uint64) {
+		seen[seq] = occurrences[seq]
+	})
+
+	if or, ok := remover.(StorageOccurrenceRemover); ok {
+		or.RemoveOccurrences(seen)
+	} else {
+		remover.Remove(slices.Collect(maps.Keys(seen))...)
+	}
+	return err
+}
+
+// seqsOf returns the sequence numbers of the records
+func seqsOf(records []storage.Record) []uint64 {
+	seqs := make([]uint64, len(records))
+	for i := range records {
+		seqs[i] = records[i].Seq
+	}
+	return seqs
+}
@@ -0,0 +1,239 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/robbyt/go-loglater/query"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestDrain(t *testing.T) {
+	t.Run("RemovesHandledRecords", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("a")
+		logger.WithGroup("g").Info("b", "key", "value")
+
+		handler := &failOnMessageHandler{}
+		if err := collector.Drain(t.Context(), handler); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+
+		if got := strings.Join(handler.handled, ","); got != "a,b" {
+			t.Errorf("Expected a,b handled, got %s", got)
+		}
+		if logs := collector.GetLogs(); len(logs) != 0 {
+			t.Errorf("Expected empty collector after drain, got %d logs", len(logs))
+		}
+
+		logger.Info("c")
+		if logs := collector.GetLogs(); len(logs) != 1 || logs[0].Message != "c" {
+			t.Errorf("Expected only the new record after drain, got %v", logs)
+		}
+	})
+
+	t.Run("Router", func(t *testing.T) {
+		router := storage.NewRouter(
+			storage.WithRoute("errors", func(r *storage.Record) bool { return r.Level >= slog.LevelError }),
+			storage.WithRoute("db", query.MustCompile(`component == "db"`).Match),
+		)
+		collector := NewLogCollector(nil, WithStorage(router))
+		logger := slog.New(collector)
+		logger.With("component", "db").Error("a")
+		logger.Info("dropped")
+		logger.With("component", "db").Info("b")
+		logger.Error("c")
+
+		handler := &failOnMessageHandler{}
+		if err := collector.Drain(t.Context(), handler); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "a,b,c" {
+			t.Errorf("Expected a,b,c handled once each, got %s", got)
+		}
+		if logs := router.Store("db").GetAll(); len(logs) != 0 {
+			t.Errorf("Expected drained records removed from every store, got %d", len(logs))
+		}
+	})
+
+	t.Run("KeepsFailedRecords", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		for _, msg := range []string{"a", "b", "c"} {
+			logger.Info(msg)
+		}
+
+		handler := &failOnMessageHandler{fail: "b"}
+		err := collector.Drain(t.Context(), handler)
+		if !errors.Is(err, errHandlerFailed) {
+			t.Fatalf("Expected errHandlerFailed, got %v", err)
+		}
+
+		logs := collector.GetLogs()
+		if len(logs) != 2 || logs[0].Message != "b" || logs[1].Message != "c" {
+			t.Fatalf("Expected b,c to remain, got %v", logs)
+		}
+
+		handler.fail = ""
+		if err := collector.Drain(t.Context(), handler); err != nil {
+			t.Fatalf("Drain retry failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "a,b,c" {
+			t.Errorf("Expected a,b,c handled once each, got %s", got)
+		}
+	})
+
+	t.Run("KeepsDuplicatesLoggedDuringDrain", func(t *testing.T) {
+		collector := NewLogCollector(nil, WithStorage(storage.NewRecordStorage(storage.WithDedup(0))))
+		logger := slog.New(collector)
+		logger.Warn("retrying")
+		logger.Warn("retrying")
+
+		// Two more occurrences arrive after the record was read but before it is removed
+		logged := WithProgress(func(done, total int) {
+			logger.Warn("retrying")
+			logger.Warn("retrying")
+		})
+		if err := collector.Drain(t.Context(), &failOnMessageHandler{}, logged); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+
+		logs := collector.GetLogs()
+		if len(logs) != 1 || logs[0].Occurrences() != 2 {
+			t.Fatalf("Expected the 2 new occurrences to remain, got %v", logs)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("a")
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if err := collector.Drain(ctx, &failOnMessageHandler{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if logs := collector.GetLogs(); len(logs) != 1 {
+			t.Errorf("Expected record to remain after canceled drain, got %d", len(logs))
+		}
+	})
+
+	t.Run("NilHandler", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		if err := collector.Drain(t.Context(), nil); err == nil {
+			t.Error("Expected error for nil handler")
+		}
+	})
+
+	t.Run("UnsupportedStorage", func(t *testing.T) {
+		collector := NewLogCollector(nil, WithStorage(&sliceStorage{}))
+		err := collector.Drain(t.Context(), &failOnMessageHandler{})
+		if !errors.Is(err, ErrRemoveUnsupported) {
+			t.Errorf("Expected ErrRemoveUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("ConcurrentDrainsHandleEachRecordOnce", func(t *testing.T) {
+		collector := NewLogCollector(nil, WithStorage(storage.NewRecordStorage()))
+		logger := slog.New(collector)
+		for range 100 {
+			logger.Info("msg")
+		}
+
+		var mu sync.Mutex
+		count := 0
+		counter := &countingHandler{mu: &mu, count: &count}
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				derived := collector.WithAttrs([]slog.Attr{slog.String("k", "v")}).(*LogCollector)
+				if err := derived.Drain(t.Context(), counter); err != nil {
+					t.Errorf("Drain failed: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+
+		if count != 100 {
+			t.Errorf("Expected 100 records handled, got %d", count)
+		}
+	})
+
+	t.Run("ConcurrentDrainsOfCollectorsSharingAStore", func(t *testing.T) {
+		for _, tt := range []struct {
+			name  string
+			store Storage
+		}{
+			{"MemStorage", storage.NewRecordStorage()},
+			{"Router", storage.NewRouter(storage.WithRoute("all", nil), storage.WithRoute("also", nil))},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				synctest.Test(t, func(t *testing.T) {
+					logger := slog.New(NewLogCollector(nil, WithStorage(tt.store)))
+					for range 100 {
+						logger.Info("msg", "delay", time.Millisecond)
+					}
+
+					handler := &slowHandler{}
+					var wg sync.WaitGroup
+					for range 4 {
+						wg.Go(func() {
+							// Separate roots do not share a drain mutex
+							collector := NewLogCollector(nil, WithStorage(tt.store))
+							if err := collector.Drain(t.Context(), handler); err != nil {
+								t.Errorf("Drain failed: %v", err)
+							}
+						})
+					}
+					wg.Wait()
+
+					if len(handler.handled) != 100 {
+						t.Errorf("Expected 100 records handled, got %d", len(handler.handled))
+					}
+				})
+			})
+		}
+	})
+
+	t.Run("ReleasesFailedRecords", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("a")
+		logger.Info("b")
+
+		if err := collector.Drain(t.Context(), &failOnMessageHandler{fail: "a"}); !errors.Is(err, errHandlerFailed) {
+			t.Fatalf("Expected errHandlerFailed, got %v", err)
+		}
+		handler := &failOnMessageHandler{}
+		if err := collector.Drain(t.Context(), handler); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "a,b" {
+			t.Errorf("Expected a,b handled by the next drain, got %s", got)
+		}
+	})
+}
+
+type countingHandler struct {
+	mu    *sync.Mutex
+	count *int
+}
+
+func (h *countingHandler) Enabled(ctx context.Context, level slog.Level) bool { return true }
+func (h *countingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.count++
+	return nil
+}
+func (h *countingHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h *countingHandler) WithGroup(name string) slog.Handler       { return h }
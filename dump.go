@@ -0,0 +1,173 @@
+package loglater
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// DefaultDumpTimeout bounds how long a dump may run before it is abandoned, so a slow
+// or blocked handler cannot keep a crashing process from exiting.
+const DefaultDumpTimeout = 5 * time.Second
+
+// Dumper replays a collector's logs to a handler when the process panics or receives
+// a signal, like a flight recorder. Dumps are best effort: replay errors are ignored,
+// and a dump still running after the timeout is abandoned.
+type Dumper struct {
+	collector *LogCollector
+	handler   slog.Handler
+	timeout   time.Duration
+	replay    []ReplayOption
+	raise     bool
+}
+
+// DumpOption configures a Dumper
+type DumpOption func(*Dumper)
+
+// WithDumpTimeout sets how long a dump may run. The default is DefaultDumpTimeout.
+func WithDumpTimeout(timeout time.Duration) DumpOption {
+	return func(d *Dumper) {
+		if timeout > 0 {
+			d.timeout = timeout
+		}
+	}
+}
+
+// WithDumpReplayOptions applies replay options, such as WithFilter, to each dump.
+func WithDumpReplayOptions(opts ...ReplayOption) DumpOption {
+	return func(d *Dumper) {
+		d.replay = append(d.replay, opts...)
+	}
+}
+
+// WithDumpRaise makes OnSignal stop listening after the first signal and send the
+// signal again once the dump is done, so that termination signals such as SIGTERM
+// still end the process after dumping.
+func WithDumpRaise() DumpOption {
+	return func(d *Dumper) {
+		d.raise = true
+	}
+}
+
+// NewDumper creates a Dumper that replays the collector's logs to the handler. To
+// dump to a file, pass a handler writing to it:
+//
+//	f, _ := os.Create("crash.log")
+//	d := loglater.NewDumper(collector, slog.NewJSONHandler(f, nil))
+func NewDumper(collector *LogCollector, handler slog.Handler, opts ...DumpOption) *Dumper {
+	d := &Dumper{
+		collector: collector,
+		handler:   handler,
+		timeout:   DefaultDumpTimeout,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Dump replays the collected logs to the handler, returning the replay error or
+// context.DeadlineExceeded when the timeout expires first.
+func (d *Dumper) Dump() error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	// The replay checks the context between records, but a single Handle call can
+	// block, so the wait is bounded here as well
+	done := make(chan error, 1)
+	go func() {
+		done <- d.collector.PlayLogsCtx(ctx, d.handler, d.replay...)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnPanic dumps the collected logs if the calling goroutine is panicking, then
+// continues the panic. It must be deferred directly:
+//
+//	defer dumper.OnPanic()
+func (d *Dumper) OnPanic() {
+	if r := recover(); r != nil {
+		_ = d.Dump()
+		panic(r)
+	}
+}
+
+// OnSignal dumps the collected logs each time the process receives one of the
+// signals, until the context is done or the returned stop function is called. At
+// least one signal must be given; without any, OnSignal does nothing.
+//
+// Listening for a signal replaces its default action, so without WithDumpRaise a
+// termination signal no longer ends the process.
+func (d *Dumper) OnSignal(ctx context.Context, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				_ = d.Dump()
+				if d.raise {
+					// Stop listening first, so the signal takes its default action
+					signal.Stop(ch)
+					raise(sig)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// raise sends the signal to the current process
+func raise(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		_ = p.Signal(sig)
+	}
+}
+
+// DumpOnPanic replays the collector's logs to the handler if the calling goroutine
+// is panicking, then continues the panic. It must be deferred directly, typically at
+// the top of main or of a goroutine:
+//
+//	defer loglater.DumpOnPanic(collector, slog.NewTextHandler(os.Stderr, nil))
+func DumpOnPanic(collector *LogCollector, handler slog.Handler) {
+	if r := recover(); r != nil {
+		_ = NewDumper(collector, handler).Dump()
+		panic(r)
+	}
+}
+
+// DumpOnSignal replays the collector's logs to the handler each time the process
+// receives one of the signals, until the context is done or stop is called:
+//
+//	stop := loglater.DumpOnSignal(ctx, collector, handler, syscall.SIGUSR1)
+//	defer stop()
+//
+// Use NewDumper for a custom timeout, or to still exit on termination signals.
+func DumpOnSignal(ctx context.Context, collector *LogCollector, handler slog.Handler, sigs ...os.Signal) (stop func()) {
+	return NewDumper(collector, handler).OnSignal(ctx, sigs...)
+}
@@ -0,0 +1,168 @@
+package loglater
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestDumper(t *testing.T) {
+	t.Run("Dump", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("first")
+		logger.Warn("second")
+
+		var buf bytes.Buffer
+		err := NewDumper(collector, slog.NewTextHandler(&buf, nil)).Dump()
+		if err != nil {
+			t.Fatalf("Dump failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "msg=first") || !strings.Contains(buf.String(), "msg=second") {
+			t.Errorf("Expected both records, got: %s", buf.String())
+		}
+	})
+
+	t.Run("ReplayOptions", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("first")
+		logger.Warn("second")
+
+		handler := &failOnMessageHandler{}
+		warnOnly := WithFilter(func(r *storage.Record) bool { return r.Level >= slog.LevelWarn })
+		if err := NewDumper(collector, handler, WithDumpReplayOptions(warnOnly)).Dump(); err != nil {
+			t.Fatalf("Dump failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "second" {
+			t.Errorf("Expected only second, got %s", got)
+		}
+	})
+
+	t.Run("HandlerError", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("boom")
+
+		err := NewDumper(collector, &failOnMessageHandler{fail: "boom"}).Dump()
+		if !errors.Is(err, errHandlerFailed) {
+			t.Errorf("Expected errHandlerFailed, got %v", err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("slow")
+
+		// The handler blocks well past the timeout, without checking the context
+		blocked := make(chan struct{})
+		t.Cleanup(func() { close(blocked) })
+		handler := &blockingHandler{release: blocked}
+
+		start := time.Now()
+		err := NewDumper(collector, handler, WithDumpTimeout(20*time.Millisecond)).Dump()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Dump took %v despite the timeout", elapsed)
+		}
+	})
+
+	t.Run("OnPanic", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Error("before the crash")
+
+		var buf bytes.Buffer
+		dumper := NewDumper(collector, slog.NewTextHandler(&buf, nil))
+
+		recovered := func() (r any) {
+			defer func() { r = recover() }()
+			defer dumper.OnPanic()
+			panic("crash")
+		}()
+
+		if recovered != "crash" {
+			t.Errorf("Expected the panic to continue with its value, got %v", recovered)
+		}
+		if !strings.Contains(buf.String(), "before the crash") {
+			t.Errorf("Expected the logs to be dumped, got: %s", buf.String())
+		}
+	})
+
+	t.Run("NoPanic", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("quiet")
+
+		handler := &failOnMessageHandler{}
+		func() {
+			defer NewDumper(collector, handler).OnPanic()
+		}()
+		if len(handler.handled) != 0 {
+			t.Errorf("Expected no dump without a panic, got %v", handler.handled)
+		}
+	})
+
+	t.Run("OnSignalWithoutSignals", func(t *testing.T) {
+		stop := NewDumper(NewLogCollector(nil), &failOnMessageHandler{}).OnSignal(t.Context())
+		stop()
+	})
+}
+
+func TestDumpOnPanic(t *testing.T) {
+	collector := NewLogCollector(nil)
+	slog.New(collector).Error("before the crash")
+
+	var buf bytes.Buffer
+	recovered := func() (r any) {
+		defer func() { r = recover() }()
+		defer DumpOnPanic(collector, slog.NewTextHandler(&buf, nil))
+		panic("crash")
+	}()
+
+	if recovered != "crash" {
+		t.Errorf("Expected the panic to continue with its value, got %v", recovered)
+	}
+	if !strings.Contains(buf.String(), "before the crash") {
+		t.Errorf("Expected the logs to be dumped, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	func() {
+		defer DumpOnPanic(collector, slog.NewTextHandler(&buf, nil))
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("Expected no dump without a panic, got: %s", buf.String())
+	}
+}
+
+// blockingHandler blocks in Handle until release is closed, ignoring the context
+type blockingHandler struct {
+	release chan struct{}
+}
+
+func (h *blockingHandler) Enabled(ctx context.Context, level slog.Level) bool { return true }
+func (h *blockingHandler) Handle(ctx context.Context, r slog.Record) error {
+	<-h.release
+	return nil
+}
+func (h *blockingHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h *blockingHandler) WithGroup(name string) slog.Handler       { return h }
+
+// notifyHandler sends each handled message on a channel
+type notifyHandler struct {
+	messages chan string
+}
+
+func (h *notifyHandler) Enabled(ctx context.Context, level slog.Level) bool { return true }
+func (h *notifyHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.messages <- r.Message
+	return nil
+}
+func (h *notifyHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h *notifyHandler) WithGroup(name string) slog.Handler       { return h }
@@ -0,0 +1,89 @@
+//go:build unix
+
+package loglater
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitMessage waits for a message from the handler
+func waitMessage(t *testing.T, messages <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a dump")
+		return ""
+	}
+}
+
+func TestDumpOnSignal(t *testing.T) {
+	collector := NewLogCollector(nil)
+	slog.New(collector).Info("buffered")
+
+	handler := &notifyHandler{messages: make(chan string, 10)}
+	stop := DumpOnSignal(t.Context(), collector, handler, syscall.SIGUSR1)
+
+	// Every signal dumps again until stop is called
+	for range 2 {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+		if msg := waitMessage(t, handler.messages); msg != "buffered" {
+			t.Errorf("Expected buffered, got %q", msg)
+		}
+	}
+
+	stop()
+
+	// Keep SIGUSR1 from ending the test process once the dumper stopped listening
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGUSR1)
+	defer signal.Stop(ignored)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	<-ignored
+	select {
+	case msg := <-handler.messages:
+		t.Errorf("Expected no dump after stop, got %q", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDumperRaise(t *testing.T) {
+	collector := NewLogCollector(nil)
+	slog.New(collector).Info("buffered")
+
+	// Stand in for the default action, which would end the test process
+	received := make(chan os.Signal, 4)
+	signal.Notify(received, syscall.SIGUSR2)
+	defer signal.Stop(received)
+
+	handler := &notifyHandler{messages: make(chan string, 10)}
+	stop := NewDumper(collector, handler, WithDumpRaise()).OnSignal(t.Context(), syscall.SIGUSR2)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	if msg := waitMessage(t, handler.messages); msg != "buffered" {
+		t.Errorf("Expected buffered, got %q", msg)
+	}
+
+	// The original signal and the one sent again after the dump
+	for i := range 2 {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected 2 signals, got %d", i)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// WriteCSV writes a header row followed by one row per record, with the columns
+// set by WithColumns. Attribute columns use flattened keys, such as "api.user" or
+// "context.trace_id", and are empty for records without the attribute. The count
+// column holds the number of occurrences of a record, and the last_seen column the
+// time of the last one for a deduplicated record with more than one occurrence.
+func WriteCSV(w io.Writer, records iter.Seq[storage.Record], opts ...Option) error {
+	cfg := newConfig(opts)
+	columns := cfg.columns
+	if len(columns) == 0 {
+		columns = []string{SeqKey, TimeKey, LevelKey, MsgKey}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for r := range records {
+		if !cfg.match(&r) {
+			continue
+		}
+
+		attrs := make(map[string]string)
+		for _, kv := range r.Flatten(cfg.sep, storage.WithDuplicates(storage.DuplicateKeepAll)) {
+			attrs[kv.Key] = valueText(kv.Value)
+		}
+		for _, kv := range flattenContext(&r, cfg.sep) {
+			attrs[ContextKey+cfg.sep+kv.Key] = valueText(kv.Value)
+		}
+
+		for i, column := range columns {
+			row[i] = csvField(&r, column, attrs)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvField returns the value of one column for a record
+func csvField(r *storage.Record, column string, attrs map[string]string) string {
+	switch column {
+	case SeqKey:
+		return strconv.FormatUint(r.Seq, 10)
+	case TimeKey:
+		if r.Time.IsZero() {
+			return ""
+		}
+		return r.Time.Format(time.RFC3339Nano)
+	case LevelKey:
+		return r.Level.String()
+	case MsgKey:
+		return r.Message
+	case CountKey:
+		return strconv.Itoa(r.Occurrences())
+	case LastSeenKey:
+		if r.Count <= 1 {
+			return ""
+		}
+		return r.LastSeen.Format(time.RFC3339Nano)
+	case SourceKey:
+		if frame, ok := source(r.PC); ok {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		return ""
+	default:
+		return attrs[column]
+	}
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	t.Run("DefaultColumns", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, slices.Values(testRecords())); err != nil {
+			t.Fatalf("WriteCSV failed: %v", err)
+		}
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		expected := [][]string{
+			{"seq", "time", "level", "msg"},
+			{"1", "2025-01-01T12:00:00Z", "INFO", "started"},
+			{"2", "", "ERROR+2", "failed request"},
+		}
+		if !slices.EqualFunc(rows, expected, slices.Equal) {
+			t.Errorf("Expected %v, got %v", expected, rows)
+		}
+	})
+
+	t.Run("AttributeColumns", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := WriteCSV(&buf, slices.Values(testRecords()), WithColumns("msg", "api.user", "elapsed", "source"))
+		if err != nil {
+			t.Fatalf("WriteCSV failed: %v", err)
+		}
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		expected := [][]string{
+			{"msg", "api.user", "elapsed", "source"},
+			{"started", "123", "", ""},
+			{"failed request", "", "1s", ""},
+		}
+		if !slices.EqualFunc(rows, expected, slices.Equal) {
+			t.Errorf("Expected %v, got %v", expected, rows)
+		}
+	})
+
+	t.Run("ContextAndCountColumns", func(t *testing.T) {
+		var buf bytes.Buffer
+		records := append(testRecords()[:1], dedupRecord())
+		err := WriteCSV(&buf, slices.Values(records), WithColumns("msg", "context.trace_id", "count", "last_seen"))
+		if err != nil {
+			t.Fatalf("WriteCSV failed: %v", err)
+		}
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		expected := [][]string{
+			{"msg", "context.trace_id", "count", "last_seen"},
+			{"started", "", "1", ""},
+			{"retrying", "abc", "3", "2025-01-01T12:01:00Z"},
+		}
+		if !slices.EqualFunc(rows, expected, slices.Equal) {
+			t.Errorf("Expected %v, got %v", expected, rows)
+		}
+	})
+
+	t.Run("WriteError", func(t *testing.T) {
+		if err := WriteCSV(failingWriter{}, slices.Values(testRecords())); err == nil {
+			t.Error("Expected write error")
+		}
+	})
+}
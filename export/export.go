@@ -0,0 +1,111 @@
+// Package export writes collected log records as newline-delimited JSON, logfmt or CSV.
+//
+// Each writer takes an iterator of realized records, such as LogCollector.All:
+//
+//	err := export.WriteNDJSON(os.Stdout, collector.All())
+//
+// or a plain slice of records through slices.Values.
+package export
+
+import (
+	"runtime"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// Standard column and field names shared by all formats
+const (
+	SeqKey    = "seq"
+	TimeKey   = "time"
+	LevelKey  = "level"
+	MsgKey    = "msg"
+	AttrsKey  = "attrs"
+	SourceKey = "source"
+
+	// ContextKey holds the attributes captured by context extractors, kept apart
+	// from the record's own attributes
+	ContextKey = "context"
+
+	// CountKey and LastSeenKey hold the occurrences of a deduplicated record and the
+	// time of the last one
+	CountKey    = "count"
+	LastSeenKey = "last_seen"
+)
+
+// Option configures an export writer
+type Option func(*config)
+
+type config struct {
+	filter  func(*storage.Record) bool
+	sep     string
+	flatten bool
+	source  bool
+	columns []string
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{sep: "."}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithFilter exports only the records for which the filter returns true.
+func WithFilter(filter func(*storage.Record) bool) Option {
+	return func(cfg *config) {
+		cfg.filter = filter
+	}
+}
+
+// WithFlatten writes NDJSON attributes as a single object with keys joined by sep,
+// such as {"api.user":"123"}, instead of nested objects. The separator is also used
+// for the keys of logfmt output and CSV attribute columns, which are always flat.
+// The default separator is ".".
+func WithFlatten(sep string) Option {
+	return func(cfg *config) {
+		cfg.flatten = true
+		cfg.sep = sep
+	}
+}
+
+// WithSource adds the source location of the log call, taken from the record's PC.
+func WithSource() Option {
+	return func(cfg *config) {
+		cfg.source = true
+	}
+}
+
+// WithColumns sets the CSV columns. Columns are the standard names seq, time, level,
+// msg, source, count and last_seen, flattened attribute keys such as "api.user", or
+// flattened context attribute keys such as "context.trace_id". The default columns
+// are seq, time, level and msg.
+func WithColumns(columns ...string) Option {
+	return func(cfg *config) {
+		cfg.columns = columns
+	}
+}
+
+// match reports whether the record passes the configured filter
+func (cfg *config) match(r *storage.Record) bool {
+	return cfg.filter == nil || cfg.filter(r)
+}
+
+// flattenContext returns the flattened context attributes of a record
+func flattenContext(r *storage.Record, sep string) []storage.KV {
+	if len(r.Context) == 0 {
+		return nil
+	}
+	captured := storage.Record{Attrs: r.Context}
+	return captured.Flatten(sep, storage.WithDuplicates(storage.DuplicateKeepAll))
+}
+
+// source returns the call site recorded in the PC, if any
+func source(pc uintptr) (runtime.Frame, bool) {
+	if pc == 0 {
+		return runtime.Frame{}, false
+	}
+	frames := runtime.CallersFrames([]uintptr{pc})
+	frame, _ := frames.Next()
+	return frame, frame.File != ""
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+var testTime = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// testRecords returns realized records covering groups, inline groups and empty attrs
+func testRecords() []storage.Record {
+	return []storage.Record{
+		{
+			Seq:     1,
+			Time:    testTime,
+			Level:   slog.LevelInfo,
+			Message: "started",
+			Attrs: []slog.Attr{
+				slog.String("service", "api"),
+				slog.Group("api", slog.String("user", "123"), slog.Int("status", 200)),
+			},
+		},
+		{
+			Seq:     2,
+			Level:   slog.LevelError + 2,
+			Message: "failed request",
+			Attrs: []slog.Attr{
+				{},
+				slog.Group("", slog.Bool("inline", true)),
+				slog.Group("empty"),
+				slog.Duration("elapsed", time.Second),
+			},
+		},
+	}
+}
+
+// dedupRecord returns a realized record with context attributes, collapsed three times
+func dedupRecord() storage.Record {
+	return storage.Record{
+		Seq:      3,
+		Time:     testTime,
+		Level:    slog.LevelWarn,
+		Message:  "retrying",
+		Attrs:    []slog.Attr{slog.Int("attempt", 2)},
+		Context:  []slog.Attr{slog.String("trace_id", "abc"), slog.Group("span", slog.String("id", "s1"))},
+		Count:    3,
+		LastSeen: testTime.Add(time.Minute),
+	}
+}
+
+func TestSource(t *testing.T) {
+	if _, ok := source(0); ok {
+		t.Error("Expected no source for zero PC")
+	}
+}
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// WriteNDJSON writes one JSON object per record with a stable schema:
+//
+//	{"seq":1,"time":"2025-01-01T12:00:00Z","level":"INFO","msg":"started","attrs":{"api":{"user":"123"}}}
+//
+// Every object has the seq, time, level, msg and attrs keys, in that order; time is
+// null for records without a time. Attributes keep their order and are nested by
+// group unless WithFlatten is used. Records with attributes captured by context
+// extractors add them in a context object, after attrs, and deduplicated records
+// with more than one occurrence add count and last_seen keys. WithSource adds a
+// source object with function, file and line keys.
+func WriteNDJSON(w io.Writer, records iter.Seq[storage.Record], opts ...Option) error {
+	cfg := newConfig(opts)
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, 0, 1024)
+
+	for r := range records {
+		if !cfg.match(&r) {
+			continue
+		}
+		buf = appendJSONRecord(buf[:0], &r, cfg)
+		buf = append(buf, '\n')
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// appendJSONRecord appends the JSON object for a record
+func appendJSONRecord(buf []byte, r *storage.Record, cfg *config) []byte {
+	buf = append(buf, `{"seq":`...)
+	buf = strconv.AppendUint(buf, r.Seq, 10)
+
+	buf = append(buf, `,"time":`...)
+	if r.Time.IsZero() {
+		buf = append(buf, "null"...)
+	} else {
+		buf = strconv.AppendQuote(buf, r.Time.Format(time.RFC3339Nano))
+	}
+
+	buf = append(buf, `,"level":`...)
+	buf = appendJSONString(buf, r.Level.String())
+	buf = append(buf, `,"msg":`...)
+	buf = appendJSONString(buf, r.Message)
+
+	buf = append(buf, `,"attrs":`...)
+	if cfg.flatten {
+		buf = append(buf, '{')
+		for i, kv := range r.Flatten(cfg.sep, storage.WithDuplicates(storage.DuplicateKeepAll)) {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, kv.Key)
+			buf = append(buf, ':')
+			buf = appendJSONValue(buf, kv.Value)
+		}
+		buf = append(buf, '}')
+	} else {
+		buf = appendJSONAttrs(buf, r.Attrs)
+	}
+
+	if len(r.Context) > 0 {
+		buf = append(buf, `,"context":`...)
+		if cfg.flatten {
+			buf = append(buf, '{')
+			for i, kv := range flattenContext(r, cfg.sep) {
+				if i > 0 {
+					buf = append(buf, ',')
+				}
+				buf = appendJSONString(buf, kv.Key)
+				buf = append(buf, ':')
+				buf = appendJSONValue(buf, kv.Value)
+			}
+			buf = append(buf, '}')
+		} else {
+			buf = appendJSONAttrs(buf, r.Context)
+		}
+	}
+
+	if r.Count > 1 {
+		buf = append(buf, `,"count":`...)
+		buf = strconv.AppendInt(buf, int64(r.Count), 10)
+		buf = append(buf, `,"last_seen":`...)
+		buf = strconv.AppendQuote(buf, r.LastSeen.Format(time.RFC3339Nano))
+	}
+
+	if cfg.source {
+		if frame, ok := source(r.PC); ok {
+			buf = append(buf, `,"source":{"function":`...)
+			buf = appendJSONString(buf, frame.Function)
+			buf = append(buf, `,"file":`...)
+			buf = appendJSONString(buf, frame.File)
+			buf = append(buf, `,"line":`...)
+			buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+			buf = append(buf, '}')
+		}
+	}
+
+	return append(buf, '}')
+}
+
+// appendJSONAttrs appends attrs as a JSON object, nesting groups and inlining
+// groups with an empty key
+func appendJSONAttrs(buf []byte, attrs []slog.Attr) []byte {
+	buf = append(buf, '{')
+	_, buf = appendJSONMembers(buf, attrs, true)
+	return append(buf, '}')
+}
+
+// appendJSONMembers appends the object members for attrs, and reports whether the
+// next member is still the first one in the object
+func appendJSONMembers(buf []byte, attrs []slog.Attr, first bool) (bool, []byte) {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+		if value.Kind() == slog.KindGroup {
+			if len(value.Group()) == 0 {
+				continue
+			}
+			if attr.Key == "" {
+				first, buf = appendJSONMembers(buf, value.Group(), first)
+				continue
+			}
+		}
+
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = appendJSONString(buf, attr.Key)
+		buf = append(buf, ':')
+		if value.Kind() == slog.KindGroup {
+			buf = appendJSONAttrs(buf, value.Group())
+		} else {
+			buf = appendJSONValue(buf, value)
+		}
+	}
+	return first, buf
+}
+
+// appendJSONValue appends a resolved, non-group value, using the same
+// representation as slog.JSONHandler
+func appendJSONValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(buf, f, 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		return strconv.AppendQuote(buf, v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		return appendJSONAttrs(buf, v.Group())
+	}
+
+	switch a := v.Any().(type) {
+	case error:
+		return appendJSONString(buf, a.Error())
+	case json.Marshaler:
+		// Compact, as indented output would split the record across lines
+		if b, err := a.MarshalJSON(); err == nil {
+			var compact bytes.Buffer
+			if json.Compact(&compact, b) == nil {
+				return append(buf, compact.Bytes()...)
+			}
+		}
+	case encoding.TextMarshaler:
+		if b, err := a.MarshalText(); err == nil {
+			return appendJSONString(buf, string(b))
+		}
+	}
+	if b, err := json.Marshal(v.Any()); err == nil {
+		return append(buf, b...)
+	}
+	return appendJSONString(buf, fmt.Sprintf("%+v", v.Any()))
+}
+
+// appendJSONString appends s as a JSON string
+func appendJSONString(buf []byte, s string) []byte {
+	b, _ := json.Marshal(s)
+	return append(buf, b...)
+}
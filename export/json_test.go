@@ -0,0 +1,164 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/query"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	t.Run("StableSchema", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteNDJSON(&buf, slices.Values(testRecords())); err != nil {
+			t.Fatalf("WriteNDJSON failed: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		expected := []string{
+			`{"seq":1,"time":"2025-01-01T12:00:00Z","level":"INFO","msg":"started","attrs":{"service":"api","api":{"user":"123","status":200}}}`,
+			`{"seq":2,"time":null,"level":"ERROR+2","msg":"failed request","attrs":{"inline":true,"elapsed":1000000000}}`,
+		}
+		if !slices.Equal(lines, expected) {
+			t.Errorf("Unexpected output:\n%s\nexpected:\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+		}
+	})
+
+	t.Run("ContextAndCount", func(t *testing.T) {
+		var buf bytes.Buffer
+		records := []storage.Record{dedupRecord()}
+		if err := WriteNDJSON(&buf, slices.Values(records)); err != nil {
+			t.Fatalf("WriteNDJSON failed: %v", err)
+		}
+		expected := `{"seq":3,"time":"2025-01-01T12:00:00Z","level":"WARN","msg":"retrying","attrs":{"attempt":2},` +
+			`"context":{"trace_id":"abc","span":{"id":"s1"}},"count":3,"last_seen":"2025-01-01T12:01:00Z"}` + "\n"
+		if buf.String() != expected {
+			t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+		}
+
+		buf.Reset()
+		if err := WriteNDJSON(&buf, slices.Values(records), WithFlatten(".")); err != nil {
+			t.Fatalf("WriteNDJSON failed: %v", err)
+		}
+		if want := `"context":{"trace_id":"abc","span.id":"s1"}`; !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %s in output, got %s", want, buf.String())
+		}
+	})
+
+	t.Run("Flatten", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteNDJSON(&buf, slices.Values(testRecords()[:1]), WithFlatten(".")); err != nil {
+			t.Fatalf("WriteNDJSON failed: %v", err)
+		}
+
+		expected := `"attrs":{"service":"api","api.user":"123","api.status":200}`
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s in output, got %s", expected, buf.String())
+		}
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		records := testRecords()[:1]
+		records[0].Attrs = []slog.Attr{
+			slog.Float64("nan", math.NaN()),
+			slog.Float64("pi", 3.5),
+			slog.Uint64("big", math.MaxUint64),
+			slog.Time("at", testTime),
+			slog.Any("err", errors.New("boom")),
+			slog.Any("list", []int{1, 2}),
+			slog.Any("raw", json.RawMessage(`{"x":1}`)),
+			slog.Any("indented", indentedMarshaler{}),
+			slog.Any("level", slog.LevelWarn),
+			slog.Any("fn", func() {}),
+			slog.String("quote", `say "hi"`),
+		}
+
+		var buf bytes.Buffer
+		if err := WriteNDJSON(&buf, slices.Values(records)); err != nil {
+			t.Fatalf("WriteNDJSON failed: %v", err)
+		}
+
+		var decoded struct {
+			Attrs map[string]any `json:"attrs"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+		}
+
+		checks := map[string]any{
+			"nan":   "NaN",
+			"pi":    3.5,
+			"at":    "2025-01-01T12:00:00Z",
+			"err":   "boom",
+			"level": "WARN",
+			"quote": `say "hi"`,
+		}
+		for key, want := range checks {
+			if decoded.Attrs[key] != want {
+				t.Errorf("Attr %s: expected %v, got %v", key, want, decoded.Attrs[key])
+			}
+		}
+		if !strings.Contains(buf.String(), `"big":18446744073709551615`) {
+			t.Errorf("Expected exact uint64 in output: %s", buf.String())
+		}
+		if !strings.Contains(buf.String(), `"raw":{"x":1}`) {
+			t.Errorf("Expected raw JSON in output: %s", buf.String())
+		}
+		if strings.Count(buf.String(), "\n") != 1 || !strings.Contains(buf.String(), `"indented":{"a":[1,2]}`) {
+			t.Errorf("Expected marshaler output compacted onto one line: %s", buf.String())
+		}
+	})
+
+	t.Run("FromCollector", func(t *testing.T) {
+		collector := loglater.NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.With("service", "api").WithGroup("req").Info("handled", "id", 7)
+		logger.Debug("skipped")
+
+		var buf bytes.Buffer
+		expr := query.MustCompile(`level >= INFO`)
+		if err := WriteNDJSON(&buf, collector.All(), WithFilter(expr.Match), WithSource()); err != nil {
+			t.Fatalf("WriteNDJSON failed: %v", err)
+		}
+
+		var decoded map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Expected a single JSON object: %v\n%s", err, buf.String())
+		}
+
+		attrs := decoded["attrs"].(map[string]any)
+		if attrs["service"] != "api" || attrs["req"].(map[string]any)["id"] != float64(7) {
+			t.Errorf("Unexpected attrs: %v", attrs)
+		}
+		src, ok := decoded["source"].(map[string]any)
+		if !ok || !strings.HasSuffix(src["file"].(string), "json_test.go") {
+			t.Errorf("Expected source pointing at json_test.go, got %v", decoded["source"])
+		}
+	})
+
+	t.Run("WriteError", func(t *testing.T) {
+		err := WriteNDJSON(failingWriter{}, slices.Values(testRecords()))
+		if err == nil {
+			t.Error("Expected write error")
+		}
+	})
+}
+
+// indentedMarshaler marshals itself as indented JSON
+type indentedMarshaler struct{}
+
+func (indentedMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte("{\n  \"a\": [\n    1,\n    2\n  ]\n}"), nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// WriteLogfmt writes one logfmt line per record:
+//
+//	time=2025-01-01T12:00:00Z level=INFO msg=started api.user=123
+//
+// Records without a time omit the time key. Group keys are joined with ".", or the
+// separator set by WithFlatten. Attributes captured by context extractors follow the
+// record's own, with keys under context, such as context.trace_id, and deduplicated
+// records with more than one occurrence add count and last_seen keys. WithSource adds
+// a source key with the file and line.
+func WriteLogfmt(w io.Writer, records iter.Seq[storage.Record], opts ...Option) error {
+	cfg := newConfig(opts)
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, 0, 1024)
+
+	for r := range records {
+		if !cfg.match(&r) {
+			continue
+		}
+		buf = appendLogfmtRecord(buf[:0], &r, cfg)
+		buf = append(buf, '\n')
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// appendLogfmtRecord appends the logfmt line for a record, without a newline
+func appendLogfmtRecord(buf []byte, r *storage.Record, cfg *config) []byte {
+	if !r.Time.IsZero() {
+		buf = appendLogfmtPair(buf, TimeKey, r.Time.Format(time.RFC3339Nano))
+	}
+	buf = appendLogfmtPair(buf, LevelKey, r.Level.String())
+	buf = appendLogfmtPair(buf, MsgKey, r.Message)
+
+	for _, kv := range r.Flatten(cfg.sep, storage.WithDuplicates(storage.DuplicateKeepAll)) {
+		buf = appendLogfmtPair(buf, kv.Key, valueText(kv.Value))
+	}
+	for _, kv := range flattenContext(r, cfg.sep) {
+		buf = appendLogfmtPair(buf, ContextKey+cfg.sep+kv.Key, valueText(kv.Value))
+	}
+
+	if r.Count > 1 {
+		buf = appendLogfmtPair(buf, CountKey, strconv.Itoa(r.Count))
+		buf = appendLogfmtPair(buf, LastSeenKey, r.LastSeen.Format(time.RFC3339Nano))
+	}
+
+	if cfg.source {
+		if frame, ok := source(r.PC); ok {
+			buf = appendLogfmtPair(buf, SourceKey, frame.File+":"+strconv.Itoa(frame.Line))
+		}
+	}
+	return buf
+}
+
+// appendLogfmtPair appends key=value, separated from any previous pair by a space
+func appendLogfmtPair(buf []byte, key, value string) []byte {
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	buf = appendLogfmtString(buf, key)
+	buf = append(buf, '=')
+	return appendLogfmtString(buf, value)
+}
+
+// appendLogfmtString appends s, quoting it when it is empty or contains
+// spaces, quotes, equals signs or non-printable characters
+func appendLogfmtString(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || !unicode.IsPrint(r)
+	}) >= 0
+}
+
+// valueText formats a resolved, non-group value as text
+func valueText(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+	}
+	return v.String()
+}
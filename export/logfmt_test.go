@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestWriteLogfmt(t *testing.T) {
+	t.Run("Lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteLogfmt(&buf, slices.Values(testRecords())); err != nil {
+			t.Fatalf("WriteLogfmt failed: %v", err)
+		}
+
+		expected := "time=2025-01-01T12:00:00Z level=INFO msg=started service=api api.user=123 api.status=200\n" +
+			"level=ERROR+2 msg=\"failed request\" inline=true elapsed=1s\n"
+		if buf.String() != expected {
+			t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+		}
+	})
+
+	t.Run("ContextAndCount", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteLogfmt(&buf, slices.Values([]storage.Record{dedupRecord()})); err != nil {
+			t.Fatalf("WriteLogfmt failed: %v", err)
+		}
+
+		expected := "time=2025-01-01T12:00:00Z level=WARN msg=retrying attempt=2 context.trace_id=abc context.span.id=s1 " +
+			"count=3 last_seen=2025-01-01T12:01:00Z\n"
+		if buf.String() != expected {
+			t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+		}
+	})
+
+	t.Run("Separator", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteLogfmt(&buf, slices.Values(testRecords()[:1]), WithFlatten("_")); err != nil {
+			t.Fatalf("WriteLogfmt failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "api_user=123") {
+			t.Errorf("Expected api_user key, got %s", buf.String())
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		var buf bytes.Buffer
+		filter := func(r *storage.Record) bool { return r.Seq == 2 }
+		if err := WriteLogfmt(&buf, slices.Values(testRecords()), WithFilter(filter)); err != nil {
+			t.Fatalf("WriteLogfmt failed: %v", err)
+		}
+		if strings.Count(buf.String(), "\n") != 1 || !strings.Contains(buf.String(), "failed request") {
+			t.Errorf("Expected only the second record, got %s", buf.String())
+		}
+	})
+
+	t.Run("Quoting", func(t *testing.T) {
+		cases := map[string]string{
+			"":          `""`,
+			"plain":     "plain",
+			"two words": `"two words"`,
+			"a=b":       `"a=b"`,
+			`q"uote`:    `"q\"uote"`,
+			"new\nline": `"new\nline"`,
+		}
+		for in, want := range cases {
+			if got := string(appendLogfmtString(nil, in)); got != want {
+				t.Errorf("appendLogfmtString(%q): expected %s, got %s", in, want, got)
+			}
+		}
+	})
+}
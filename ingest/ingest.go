@@ -0,0 +1,152 @@
+// Package ingest parses the output of slog.JSONHandler and slog.TextHandler back
+// into storage records, so that historical log files can be replayed and filtered
+// like collected logs.
+//
+//	store := storage.NewRecordStorage()
+//	if _, err := ingest.Load(store, file, ingest.FormatAuto); err != nil {
+//		return err
+//	}
+//	collector := loglater.NewLogCollector(nil, loglater.WithStorage(store))
+//	collector.PlayLogs(newHandler)
+//
+// Attribute groups are rebuilt as slog.Group attributes: nested JSON objects, and
+// dotted keys such as api.user in text output. Levels are parsed with their offsets,
+// such as INFO+2. The call site cannot be recovered, so the source attribute written
+// by handlers with AddSource is kept as an ordinary attribute.
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// Format identifies the handler output format of a log file
+type Format int
+
+const (
+	// FormatAuto detects the format of each line: lines starting with '{' are JSON.
+	FormatAuto Format = iota
+	// FormatJSON is the output of slog.JSONHandler.
+	FormatJSON
+	// FormatText is the output of slog.TextHandler.
+	FormatText
+)
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "auto"
+	case FormatJSON:
+		return "json"
+	case FormatText:
+		return "text"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// Parse parses a single line in the given format.
+func Parse(line []byte, format Format) (*storage.Record, error) {
+	switch format {
+	case FormatJSON:
+		return ParseJSON(line)
+	case FormatText:
+		return ParseText(line)
+	case FormatAuto:
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && trimmed[0] == '{' {
+			return ParseJSON(line)
+		}
+		return ParseText(line)
+	default:
+		return nil, fmt.Errorf("unknown format %v", format)
+	}
+}
+
+// Reader reads records from handler output, one record per line.
+type Reader struct {
+	r      *bufio.Reader
+	format Format
+	line   int
+}
+
+// NewReader creates a Reader for the given format.
+func NewReader(r io.Reader, format Format) *Reader {
+	return &Reader{r: bufio.NewReader(r), format: format}
+}
+
+// Next returns the next record, skipping blank lines. It returns io.EOF when the
+// input is exhausted. After a parse error, which includes the line number, Next can
+// be called again to continue with the following line.
+func (r *Reader) Next() (*storage.Record, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		r.line++
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		record, perr := Parse(line, r.format)
+		if perr != nil {
+			return nil, fmt.Errorf("ingest: line %d: %w", r.line, perr)
+		}
+		return record, nil
+	}
+}
+
+// All returns an iterator over the remaining records. Iteration stops after the
+// first error, which is yielded with a zero record.
+func (r *Reader) All() iter.Seq2[storage.Record, error] {
+	return func(yield func(storage.Record, error) bool) {
+		for {
+			record, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(storage.Record{}, err)
+				return
+			}
+			if !yield(*record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Appender receives parsed records. storage.MemStorage and any loglater.Storage
+// implement it.
+type Appender interface {
+	Append(record *storage.Record)
+}
+
+// Load parses all records from r and appends them to dst, returning the number of
+// records loaded. It stops at the first parse error.
+func Load(dst Appender, r io.Reader, format Format) (int, error) {
+	reader := NewReader(r, format)
+	n := 0
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		dst.Append(record)
+		n++
+	}
+}
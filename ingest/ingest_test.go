@@ -0,0 +1,134 @@
+package ingest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// writeSampleLogs logs a fixed set of records through the handler
+func writeSampleLogs(h slog.Handler) {
+	logger := slog.New(h)
+	logger.Info("started", "version", "1.0", "port", 8080)
+	logger.With("service", "api").WithGroup("req").Warn("slow request", "elapsed", 1500*time.Millisecond, "ok", true)
+	logger.Log(nil, slog.LevelInfo+2, "custom level", "ratio", 0.5)
+	logger.WithGroup("a").WithGroup("b").Error("nested \"quote\"", "x", "two words", "y", -3)
+}
+
+func TestRoundTrip(t *testing.T) {
+	cases := []struct {
+		name       string
+		newHandler func(io.Writer) slog.Handler
+		format     Format
+	}{
+		{"JSON", func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, nil) }, FormatJSON},
+		{"Text", func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, nil) }, FormatText},
+		{"AutoJSON", func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, nil) }, FormatAuto},
+		{"AutoText", func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, nil) }, FormatAuto},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var original bytes.Buffer
+			writeSampleLogs(tc.newHandler(&original))
+
+			store := storage.NewRecordStorage()
+			n, err := Load(store, bytes.NewReader(original.Bytes()), tc.format)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if n != 4 {
+				t.Fatalf("Expected 4 records, got %d", n)
+			}
+
+			collector := loglater.NewLogCollector(nil, loglater.WithStorage(store))
+			var replayed bytes.Buffer
+			if err := collector.PlayLogs(tc.newHandler(&replayed)); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+
+			if replayed.String() != original.String() {
+				t.Errorf("Replay differs from original:\noriginal:\n%s\nreplayed:\n%s", original.String(), replayed.String())
+			}
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	t.Run("SkipsBlankLinesAndContinuesAfterErrors", func(t *testing.T) {
+		input := "level=INFO msg=one\n\n   \nnot a record\nlevel=INFO msg=two"
+		reader := NewReader(strings.NewReader(input), FormatText)
+
+		r, err := reader.Next()
+		if err != nil || r.Message != "one" {
+			t.Fatalf("Expected first record, got %v, %v", r, err)
+		}
+
+		_, err = reader.Next()
+		if err == nil || !strings.Contains(err.Error(), "line 4") {
+			t.Fatalf("Expected error on line 4, got %v", err)
+		}
+
+		r, err = reader.Next()
+		if err != nil || r.Message != "two" {
+			t.Fatalf("Expected second record without trailing newline, got %v, %v", r, err)
+		}
+
+		if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected io.EOF, got %v", err)
+		}
+	})
+
+	t.Run("All", func(t *testing.T) {
+		input := `{"level":"INFO","msg":"one"}` + "\n" + `{"level":"INFO","msg":"two"}` + "\n{bad\n" + `{"msg":"three"}`
+		var messages []string
+		var lastErr error
+		for r, err := range NewReader(strings.NewReader(input), FormatJSON).All() {
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			messages = append(messages, r.Message)
+		}
+
+		if strings.Join(messages, ",") != "one,two" {
+			t.Errorf("Expected one,two before the error, got %v", messages)
+		}
+		if lastErr == nil || !strings.Contains(lastErr.Error(), "line 3") {
+			t.Errorf("Expected error on line 3, got %v", lastErr)
+		}
+	})
+
+	t.Run("LoadStopsAtError", func(t *testing.T) {
+		store := storage.NewRecordStorage()
+		n, err := Load(store, strings.NewReader("msg=ok\nmsg=\"unterminated\n"), FormatText)
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		if n != 1 || len(store.GetAll()) != 1 {
+			t.Errorf("Expected 1 record loaded before the error, got %d", n)
+		}
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		if _, err := Parse([]byte("msg=x"), Format(99)); err == nil {
+			t.Error("Expected error for unknown format")
+		}
+	})
+}
+
+func TestFormatString(t *testing.T) {
+	cases := map[Format]string{FormatAuto: "auto", FormatJSON: "json", FormatText: "text", Format(9): "Format(9)"}
+	for f, want := range cases {
+		if f.String() != want {
+			t.Errorf("Expected %q, got %q", want, f.String())
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// ParseJSON parses one line written by slog.JSONHandler. The first time, level and
+// msg keys fill the record's fields; all other keys become attributes in their
+// original order, with nested objects as groups.
+func ParseJSON(line []byte) (*storage.Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	record := &storage.Record{Attrs: make([]slog.Attr, 0)}
+	var seenTime, seenLevel, seenMsg bool
+
+	for dec.More() {
+		key, err := readKey(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case key == slog.TimeKey && !seenTime:
+			seenTime = true
+			if record.Time, err = decodeTime(dec); err != nil {
+				return nil, err
+			}
+			continue
+		case key == slog.LevelKey && !seenLevel:
+			seenLevel = true
+			if record.Level, err = decodeLevel(dec); err != nil {
+				return nil, err
+			}
+			continue
+		case key == slog.MessageKey && !seenMsg:
+			seenMsg = true
+			if err := dec.Decode(&record.Message); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", slog.MessageKey, err)
+			}
+			continue
+		}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		record.Attrs = append(record.Attrs, slog.Attr{Key: key, Value: value})
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid JSON record: trailing data")
+	}
+	return record, nil
+}
+
+// expectDelim reads the next token and checks that it is the delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON record: %w", err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("invalid JSON record: expected %q, got %v", delim, tok)
+	}
+	return nil
+}
+
+// readKey reads an object key
+func readKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON record: %w", err)
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid JSON record: expected key, got %v", tok)
+	}
+	return key, nil
+}
+
+// decodeTime decodes an RFC 3339 time string
+func decodeTime(dec *json.Decoder) (time.Time, error) {
+	var s string
+	if err := dec.Decode(&s); err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", slog.TimeKey, err)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", slog.TimeKey, err)
+	}
+	return t, nil
+}
+
+// decodeLevel decodes a level name such as "WARN" or "INFO+2"
+func decodeLevel(dec *json.Decoder) (slog.Level, error) {
+	var s string
+	if err := dec.Decode(&s); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", slog.LevelKey, err)
+	}
+	return parseLevel(s)
+}
+
+// parseLevel parses a level name with an optional offset, such as "INFO+2"
+func parseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", slog.LevelKey, err)
+	}
+	return level, nil
+}
+
+// decodeValue decodes the next JSON value as a slog.Value
+func decodeValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, fmt.Errorf("invalid JSON record: %w", err)
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			attrs := make([]slog.Attr, 0)
+			for dec.More() {
+				key, err := readKey(dec)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				value, err := decodeValue(dec)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				attrs = append(attrs, slog.Attr{Key: key, Value: value})
+			}
+			if err := expectDelim(dec, '}'); err != nil {
+				return slog.Value{}, err
+			}
+			return slog.GroupValue(attrs...), nil
+		case '[':
+			list := make([]any, 0)
+			for dec.More() {
+				var item any
+				if err := dec.Decode(&item); err != nil {
+					return slog.Value{}, fmt.Errorf("invalid JSON record: %w", err)
+				}
+				list = append(list, item)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return slog.Value{}, err
+			}
+			return slog.AnyValue(list), nil
+		}
+		return slog.Value{}, fmt.Errorf("invalid JSON record: unexpected %v", v)
+	case string:
+		return slog.StringValue(v), nil
+	case json.Number:
+		return numberValue(v.String()), nil
+	case bool:
+		return slog.BoolValue(v), nil
+	case nil:
+		return slog.AnyValue(nil), nil
+	}
+	return slog.Value{}, fmt.Errorf("invalid JSON record: unexpected %v", tok)
+}
+
+// numberValue converts a JSON number to the narrowest matching slog kind
+func numberValue(s string) slog.Value {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return slog.Int64Value(i)
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return slog.Uint64Value(u)
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return slog.Float64Value(f)
+}
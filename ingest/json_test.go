@@ -0,0 +1,85 @@
+package ingest
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseJSON(t *testing.T) {
+	t.Run("Fields", func(t *testing.T) {
+		line := `{"time":"2025-01-01T12:00:00.123456789Z","level":"WARN+1","msg":"hello","source":{"file":"a.go","line":3},"a":{"b":{"c":1}},"msg":"dup"}`
+		r, err := ParseJSON([]byte(line))
+		if err != nil {
+			t.Fatalf("ParseJSON failed: %v", err)
+		}
+
+		if !r.Time.Equal(time.Date(2025, 1, 1, 12, 0, 0, 123456789, time.UTC)) {
+			t.Errorf("Unexpected time %v", r.Time)
+		}
+		if r.Level != slog.LevelWarn+1 {
+			t.Errorf("Expected WARN+1, got %v", r.Level)
+		}
+		if r.Message != "hello" {
+			t.Errorf("Expected hello, got %q", r.Message)
+		}
+
+		keys := make([]string, len(r.Attrs))
+		for i, a := range r.Attrs {
+			keys[i] = a.Key
+		}
+		if len(keys) != 3 || keys[0] != "source" || keys[1] != "a" || keys[2] != "msg" {
+			t.Errorf("Expected source, a and duplicate msg attributes in order, got %v", keys)
+		}
+		if v, ok := r.Find("a", "b", "c"); !ok || v.Int64() != 1 {
+			t.Errorf("Expected nested group a.b.c=1, got %v", v)
+		}
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		line := `{"msg":"m","i":-5,"u":18446744073709551615,"f":1.5,"b":false,"n":null,"s":"x","l":[1,"two"]}`
+		r, err := ParseJSON([]byte(line))
+		if err != nil {
+			t.Fatalf("ParseJSON failed: %v", err)
+		}
+
+		kinds := map[string]slog.Kind{
+			"i": slog.KindInt64,
+			"u": slog.KindUint64,
+			"f": slog.KindFloat64,
+			"b": slog.KindBool,
+			"n": slog.KindAny,
+			"s": slog.KindString,
+			"l": slog.KindAny,
+		}
+		for _, a := range r.Attrs {
+			if a.Value.Kind() != kinds[a.Key] {
+				t.Errorf("Attr %s: expected kind %v, got %v", a.Key, kinds[a.Key], a.Value.Kind())
+			}
+		}
+		if !r.Time.IsZero() {
+			t.Errorf("Expected zero time, got %v", r.Time)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cases := []string{
+			``,
+			`[]`,
+			`{"msg":`,
+			`{"time":"yesterday"}`,
+			`{"time":5}`,
+			`{"level":"LOUD"}`,
+			`{"level":1}`,
+			`{"msg":1}`,
+			`{"msg":"a"} extra`,
+			`{"a":{"b":}}`,
+			`{"a":[1,}`,
+		}
+		for _, line := range cases {
+			if _, err := ParseJSON([]byte(line)); err == nil {
+				t.Errorf("Expected error for %q", line)
+			}
+		}
+	})
+}
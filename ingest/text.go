@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// textTimeFormat is the time layout used by slog.TextHandler
+const textTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// ParseText parses one line written by slog.TextHandler. The first time, level and
+// msg keys fill the record's fields; all other keys become attributes in their
+// original order, with dotted keys such as api.user rebuilt into groups.
+//
+// Text output does not record value types, so unquoted values are converted to the
+// first kind that parses them without changing their text: integer, floating point
+// number, boolean, RFC 3339 time or duration. Quoted values and everything else are
+// strings.
+func ParseText(line []byte) (*storage.Record, error) {
+	s := strings.TrimRight(string(line), "\r\n")
+	record := &storage.Record{}
+	root := &groupBuilder{}
+	var seenTime, seenLevel, seenMsg bool
+
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			break
+		}
+
+		key, rest, err := readTextToken(s, "= ")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(rest, "=") {
+			return nil, fmt.Errorf("invalid text record: missing '=' after key %q", key.text)
+		}
+		value, rest, err := readTextToken(rest[1:], " ")
+		if err != nil {
+			return nil, err
+		}
+		s = rest
+
+		switch {
+		case key.text == slog.TimeKey && !seenTime:
+			seenTime = true
+			t, err := time.Parse(time.RFC3339Nano, value.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", slog.TimeKey, err)
+			}
+			record.Time = t
+		case key.text == slog.LevelKey && !seenLevel:
+			seenLevel = true
+			if record.Level, err = parseLevel(value.text); err != nil {
+				return nil, err
+			}
+		case key.text == slog.MessageKey && !seenMsg:
+			seenMsg = true
+			record.Message = value.text
+		default:
+			v := slog.StringValue(value.text)
+			if !value.quoted {
+				v = inferValue(value.text)
+			}
+			root.add(strings.Split(key.text, "."), v)
+		}
+	}
+
+	record.Attrs = root.attrs()
+	return record, nil
+}
+
+// textToken is a key or value read from a text record
+type textToken struct {
+	text   string
+	quoted bool
+}
+
+// readTextToken reads a quoted string, or an unquoted run of characters ending at
+// any of the stop characters, and returns it with the remaining input
+func readTextToken(s, stop string) (textToken, string, error) {
+	if strings.HasPrefix(s, `"`) {
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return textToken{}, "", fmt.Errorf("invalid text record: bad quoted string at %q", s)
+		}
+		text, err := strconv.Unquote(quoted)
+		if err != nil {
+			return textToken{}, "", fmt.Errorf("invalid text record: %w", err)
+		}
+		return textToken{text: text, quoted: true}, s[len(quoted):], nil
+	}
+
+	end := strings.IndexAny(s, stop)
+	if end < 0 {
+		end = len(s)
+	}
+	return textToken{text: s[:end]}, s[end:], nil
+}
+
+// inferValue converts unquoted text to the first slog kind that parses it and
+// formats back to the same text, so that replaying reproduces the original line
+func inferValue(s string) slog.Value {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return slog.Int64Value(i)
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil && strconv.FormatUint(u, 10) == s {
+		return slog.Uint64Value(u)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'g', -1, 64) == s {
+		return slog.Float64Value(f)
+	}
+	if s == "true" || s == "false" {
+		return slog.BoolValue(s == "true")
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil &&
+		(t.Format(textTimeFormat) == s || t.Format(time.RFC3339Nano) == s) {
+		return slog.TimeValue(t)
+	}
+	if d, err := time.ParseDuration(s); err == nil && d.String() == s {
+		return slog.DurationValue(d)
+	}
+	return slog.StringValue(s)
+}
+
+// groupBuilder rebuilds nested groups from dotted keys, keeping the order in
+// which keys first appear
+type groupBuilder struct {
+	entries []groupEntry
+}
+
+type groupEntry struct {
+	key   string
+	value slog.Value
+	group *groupBuilder // non-nil for groups
+}
+
+// add places the value at the path, creating or extending groups as needed
+func (b *groupBuilder) add(path []string, value slog.Value) {
+	if len(path) == 1 {
+		b.entries = append(b.entries, groupEntry{key: path[0], value: value})
+		return
+	}
+
+	for i := len(b.entries) - 1; i >= 0; i-- {
+		if e := b.entries[i]; e.group != nil && e.key == path[0] {
+			e.group.add(path[1:], value)
+			return
+		}
+	}
+
+	child := &groupBuilder{}
+	child.add(path[1:], value)
+	b.entries = append(b.entries, groupEntry{key: path[0], group: child})
+}
+
+// attrs returns the built attributes
+func (b *groupBuilder) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(b.entries))
+	for _, e := range b.entries {
+		if e.group != nil {
+			attrs = append(attrs, slog.Attr{Key: e.key, Value: slog.GroupValue(e.group.attrs()...)})
+		} else {
+			attrs = append(attrs, slog.Attr{Key: e.key, Value: e.value})
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,80 @@
+package ingest
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseText(t *testing.T) {
+	t.Run("Fields", func(t *testing.T) {
+		line := `time=2025-01-01T12:00:00.000Z level=DEBUG-2 msg="hello world" api.user=123 api.name="a b" "odd key"=1 other=x api.more=y` + "\r\n"
+		r, err := ParseText([]byte(line))
+		if err != nil {
+			t.Fatalf("ParseText failed: %v", err)
+		}
+
+		if !r.Time.Equal(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected time %v", r.Time)
+		}
+		if r.Level != slog.LevelDebug-2 {
+			t.Errorf("Expected DEBUG-2, got %v", r.Level)
+		}
+		if r.Message != "hello world" {
+			t.Errorf("Expected 'hello world', got %q", r.Message)
+		}
+
+		if len(r.Attrs) != 3 || r.Attrs[0].Key != "api" || r.Attrs[1].Key != "odd key" || r.Attrs[2].Key != "other" {
+			t.Fatalf("Expected api, odd key and other attributes, got %v", r.Attrs)
+		}
+		if group := r.Attrs[0].Value.Group(); len(group) != 3 {
+			t.Errorf("Expected api group with 3 members, got %v", group)
+		}
+		if v, ok := r.Find("api", "more"); !ok || v.String() != "y" {
+			t.Errorf("Expected api.more=y, got %v", v)
+		}
+	})
+
+	t.Run("InferredValues", func(t *testing.T) {
+		line := `i=-5 u=18446744073709551615 f=1.5 b=true t=2025-01-01T12:00:00Z d=1.5s s=abc q="123" nan=NaN e= v=1.0 z=007`
+		r, err := ParseText([]byte(line))
+		if err != nil {
+			t.Fatalf("ParseText failed: %v", err)
+		}
+
+		kinds := map[string]slog.Kind{
+			"i":   slog.KindInt64,
+			"u":   slog.KindUint64,
+			"f":   slog.KindFloat64,
+			"b":   slog.KindBool,
+			"t":   slog.KindTime,
+			"d":   slog.KindDuration,
+			"s":   slog.KindString,
+			"q":   slog.KindString,
+			"nan": slog.KindFloat64,
+			"e":   slog.KindString,
+			"v":   slog.KindString,
+			"z":   slog.KindString,
+		}
+		for _, a := range r.Attrs {
+			if a.Value.Kind() != kinds[a.Key] {
+				t.Errorf("Attr %s: expected kind %v, got %v", a.Key, kinds[a.Key], a.Value.Kind())
+			}
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cases := []string{
+			`novalue`,
+			`msg="unterminated`,
+			`"bad key`,
+			`time=yesterday`,
+			`level=LOUD`,
+		}
+		for _, line := range cases {
+			if _, err := ParseText([]byte(line)); err == nil {
+				t.Errorf("Expected error for %q", line)
+			}
+		}
+	})
+}
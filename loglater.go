@@ -16,13 +16,17 @@ package loglater
 import (
 	"context"
 	"errors"
+	"iter"
 	"log/slog"
 	"slices"
+	"sync"
 
 	"github.com/robbyt/go-loglater/storage"
 )
 
-// StorageWriter writes log records to a storage backend
+// StorageWriter writes log records to a storage backend. Append must give each record
+// a Seq greater than that of the records before it, as MemStorage does; cursors,
+// PlayFrom and Drain find records by it.
 type StorageWriter interface {
 	Append(record *storage.Record)
 }
@@ -40,9 +44,15 @@ type Storage interface {
 
 // LogCollector collects log records and can replay them later
 type LogCollector struct {
-	store   Storage
-	handler slog.Handler
-	journal storage.OperationJournal
+	name     string
+	store    Storage
+	handler  slog.Handler
+	journal  storage.OperationJournal
+	drainMu  *sync.Mutex // shared by all collectors derived from the same root, for stores that do not claim
+	redactor Redactor
+	sampler  Sampler
+
+	extractors []ContextExtractor
 }
 
 // NewLogCollector creates a new log collector with an underlying handler and optional configuration
@@ -51,6 +61,7 @@ func NewLogCollector(baseHandler slog.Handler, opts ...Option) *LogCollector {
 		store:   storage.NewRecordStorage(),
 		handler: baseHandler,
 		journal: make(storage.OperationJournal, 0),
+		drainMu: &sync.Mutex{},
 	}
 
 	// Apply all options
@@ -68,8 +79,18 @@ func (c *LogCollector) Handle(ctx context.Context, r slog.Record) error {
 	if storedRecord == nil {
 		return errors.New("failed to create record")
 	}
+	if len(c.extractors) > 0 {
+		storedRecord.Context = c.extractContext(ctx)
+	}
 
-	c.store.Append(storedRecord)
+	if c.sampler == nil || c.sampler.Sample(storedRecord) {
+		if summarizer, ok := c.sampler.(SampleSummarizer); ok {
+			if summary := summarizer.Summary(); summary != nil {
+				c.append(summary)
+			}
+		}
+		c.append(storedRecord)
+	}
 
 	// Forward to underlying handler if it exists
 	if c.handler != nil {
@@ -78,6 +99,14 @@ func (c *LogCollector) Handle(ctx context.Context, r slog.Record) error {
 	return nil
 }
 
+// append redacts the record, if configured, and stores it
+func (c *LogCollector) append(record *storage.Record) {
+	if c.redactor != nil {
+		c.redactor.Redact(record)
+	}
+	c.store.Append(record)
+}
+
 // Enabled implements slog.Handler.Enabled
 func (c *LogCollector) Enabled(ctx context.Context, level slog.Level) bool {
 	if c.handler == nil {
@@ -107,11 +136,7 @@ func (c *LogCollector) WithAttrs(attrs []slog.Attr) slog.Handler {
 	})
 
 	// Create a new collector that shares the same record store
-	return &LogCollector{
-		store:   c.store,
-		handler: newHandler,
-		journal: journalCopy,
-	}
+	return c.derive(newHandler, journalCopy)
 }
 
 // WithGroup implements slog.Handler.WithGroup
@@ -135,64 +160,87 @@ func (c *LogCollector) WithGroup(name string) slog.Handler {
 	})
 
 	// Create a new collector that shares the same record store
-	return &LogCollector{
-		store:   c.store,
-		handler: newHandler,
-		journal: journalCopy,
-	}
+	return c.derive(newHandler, journalCopy)
+}
+
+// derive returns a copy of the collector with a new handler and journal, sharing
+// the record store and all other configuration with the receiver.
+func (c *LogCollector) derive(handler slog.Handler, journal storage.OperationJournal) *LogCollector {
+	derived := *c
+	derived.handler = handler
+	derived.journal = journal
+	return &derived
 }
 
 // PlayLogsCtx outputs all stored logs to the provided handler with context support
-func (c *LogCollector) PlayLogsCtx(ctx context.Context, handler slog.Handler) error {
+func (c *LogCollector) PlayLogsCtx(ctx context.Context, handler slog.Handler, opts ...ReplayOption) error {
 	if handler == nil {
 		return errors.New("handler is nil")
 	}
 
-	for _, stored := range c.store.GetAll() {
-		select {
-		case <-ctx.Done():
-			// handle context cancellation between log entries
-			return ctx.Err()
-		default:
-			// continue processing
-		}
+	return newReplayConfig(opts).replay(ctx, handler, c.store.GetAll(), nil)
+}
 
-		currentHandler := handler
+// playRecord replays a single stored record to the handler, checking for context
+// cancellation before doing any work.
+func playRecord(ctx context.Context, handler slog.Handler, stored *storage.Record) error {
+	select {
+	case <-ctx.Done():
+		// handle context cancellation between log entries
+		return ctx.Err()
+	default:
+		// continue processing
+	}
 
-		// Replay the journal of WithAttrs/WithGroup operations
-		for _, op := range stored.Journal {
-			switch op.Type {
-			case storage.OpAttrs:
-				currentHandler = currentHandler.WithAttrs(op.Attrs)
-			case storage.OpGroup:
-				currentHandler = currentHandler.WithGroup(op.Group)
-			}
-		}
+	currentHandler := handler
 
-		// Create a new record from the stored data, preserving the original PC
-		r := slog.NewRecord(stored.Time, stored.Level, stored.Message, stored.PC)
-		for _, attr := range stored.Attrs {
-			r.AddAttrs(attr)
+	// Replay the journal of WithAttrs/WithGroup operations
+	for _, op := range stored.Journal {
+		switch op.Type {
+		case storage.OpAttrs:
+			currentHandler = currentHandler.WithAttrs(op.Attrs)
+		case storage.OpGroup:
+			currentHandler = currentHandler.WithGroup(op.Group)
 		}
+	}
 
-		// Forward to the new handler from this function's input
-		if err := currentHandler.Handle(ctx, r); err != nil {
-			return err
-		}
+	// Create a new record from the stored data, preserving the original PC
+	r := slog.NewRecord(stored.Time, stored.Level, stored.Message, stored.PC)
+	for _, attr := range stored.Attrs {
+		r.AddAttrs(attr)
 	}
-	return nil
+
+	// Forward to the new handler from this function's input
+	return currentHandler.Handle(ctx, r)
 }
 
 // PlayLogs outputs all stored logs to the provided handler using a background context
-func (c *LogCollector) PlayLogs(handler slog.Handler) error {
-	return c.PlayLogsCtx(context.Background(), handler)
+func (c *LogCollector) PlayLogs(handler slog.Handler, opts ...ReplayOption) error {
+	return c.PlayLogsCtx(context.Background(), handler, opts...)
 }
 
 // GetLogs returns a copy of the collected logs with all attributes and groups applied.
-// Each returned record contains the same attributes that would be present during replay.
+// Each returned record contains the same attributes that would be present during replay,
+// normalized as described on storage.Record.Realize, and has no Journal.
 func (c *LogCollector) GetLogs() []storage.Record {
 	// Get raw records and realize them for the user
-	rawRecords := c.store.GetAll()
+	return realizeAll(c.store.GetAll())
+}
+
+// All returns an iterator over the collected logs with all attributes and groups
+// applied, in the order they were stored.
+func (c *LogCollector) All() iter.Seq[storage.Record] {
+	return func(yield func(storage.Record) bool) {
+		for _, record := range c.store.GetAll() {
+			if !yield(record.Realize()) {
+				return
+			}
+		}
+	}
+}
+
+// realizeAll returns the realized form of each raw record
+func realizeAll(rawRecords []storage.Record) []storage.Record {
 	realizedRecords := make([]storage.Record, len(rawRecords))
 
 	for i, record := range rawRecords {
@@ -729,3 +729,28 @@ func (t *testCollectorWithFailure) Handle(ctx context.Context, r slog.Record) er
 	}
 	return nil
 }
+
+func TestAll(t *testing.T) {
+	collector := NewLogCollector(nil)
+	logger := slog.New(collector).WithGroup("g")
+	logger.Info("first", "k", 1)
+	logger.Info("second")
+	logger.Info("third")
+
+	var messages []string
+	for r := range collector.All() {
+		if r.Message == "first" {
+			if _, ok := r.Find("g", "k"); !ok {
+				t.Error("Expected realized record from All")
+			}
+		}
+		messages = append(messages, r.Message)
+		if len(messages) == 2 {
+			break
+		}
+	}
+
+	if strings.Join(messages, ",") != "first,second" {
+		t.Errorf("Expected first,second, got %v", messages)
+	}
+}
@@ -0,0 +1,254 @@
+package loglatertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// UpdateEnv is the environment variable that makes Golden rewrite golden files
+// when set to a true value such as "1"
+const UpdateEnv = "LOGLATER_UPDATE_GOLDEN"
+
+// updating reports whether UpdateEnv is set to a true value
+func updating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return update
+}
+
+// maskedValue replaces the values of masked attributes
+const maskedValue = "<masked>"
+
+// GoldenOption configures how Golden renders records
+type GoldenOption func(*goldenConfig)
+
+type goldenConfig struct {
+	update       bool
+	relativeTime bool
+	sortAttrs    bool
+	masks        map[string]bool
+}
+
+// WithUpdate rewrites the golden file when update is true, for tests that define
+// their own flag for it:
+//
+//	var update = flag.Bool("update", false, "rewrite golden files")
+//
+//	loglatertest.Golden(t, collector, "testdata/startup.golden", loglatertest.WithUpdate(*update))
+func WithUpdate(update bool) GoldenOption {
+	return func(cfg *goldenConfig) {
+		cfg.update = update
+	}
+}
+
+// WithRelativeTime renders each record's time as the offset from the first record,
+// such as time=+1.5s, instead of leaving it out.
+func WithRelativeTime() GoldenOption {
+	return func(cfg *goldenConfig) {
+		cfg.relativeTime = true
+	}
+}
+
+// WithSortedAttrs renders the attributes of each record and group sorted by key,
+// so golden files do not depend on the order in which attributes were added.
+func WithSortedAttrs() GoldenOption {
+	return func(cfg *goldenConfig) {
+		cfg.sortAttrs = true
+	}
+}
+
+// WithMask renders the values of the attributes at the dotted paths as <masked>,
+// for values that change between runs such as request IDs or durations.
+func WithMask(paths ...string) GoldenOption {
+	return func(cfg *goldenConfig) {
+		for _, path := range paths {
+			cfg.masks[path] = true
+		}
+	}
+}
+
+// Golden compares the collected records with the golden file at path, failing the
+// test with a line diff when they differ. Running the tests with UpdateEnv set, or
+// passing WithUpdate(true), rewrites the file instead:
+//
+//	loglatertest.Golden(t, collector, "testdata/startup.golden")
+//
+//	LOGLATER_UPDATE_GOLDEN=1 go test ./...
+//
+// Records are rendered one per line in text handler format, without the time or
+// source location, so the output is the same on every run.
+func Golden(t testing.TB, c *loglater.LogCollector, path string, opts ...GoldenOption) {
+	t.Helper()
+	cfg := &goldenConfig{update: updating(), masks: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	got := renderGolden(c.GetLogs(), cfg)
+
+	if cfg.update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating golden file directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+			return
+		}
+		t.Logf("updated golden file %s", path)
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist; run the test with %s=1 to create it", path, UpdateEnv)
+		return
+	}
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+		return
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("logs differ from golden file %s (-want +got):\n%s\nrun the test with %s=1 to accept the new output",
+			path, lineDiff(string(want), string(got)), UpdateEnv)
+	}
+}
+
+// renderGolden renders the records in the normalized golden file format
+func renderGolden(records []storage.Record, cfg *goldenConfig) []byte {
+	var buf bytes.Buffer
+	var start time.Time
+	if len(records) > 0 {
+		start = records[0].Time
+	}
+
+	for _, r := range records {
+		h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					if !cfg.relativeTime {
+						return slog.Attr{}
+					}
+					return slog.String(slog.TimeKey, relativeTime(r.Time, start))
+				}
+				if len(cfg.masks) > 0 && cfg.masks[attrPath(groups, a.Key)] {
+					return slog.String(a.Key, maskedValue)
+				}
+				return a
+			},
+		})
+
+		attrs := r.Attrs
+		if cfg.sortAttrs {
+			attrs = sortAttrs(attrs)
+		}
+		record := slog.NewRecord(r.Time, r.Level, r.Message, 0)
+		record.AddAttrs(attrs...)
+		_ = h.Handle(context.Background(), record)
+	}
+	return buf.Bytes()
+}
+
+// attrPath returns the dotted path of an attribute within its groups
+func attrPath(groups []string, key string) string {
+	if len(groups) == 0 {
+		return key
+	}
+	return strings.Join(groups, ".") + "." + key
+}
+
+// relativeTime formats the offset of t from start, such as +1.5s
+func relativeTime(t, start time.Time) string {
+	if t.IsZero() || start.IsZero() {
+		return "+0s"
+	}
+	d := t.Sub(start)
+	if d < 0 {
+		return d.String()
+	}
+	return "+" + d.String()
+}
+
+// sortAttrs returns the attributes sorted by key, recursively within groups. Groups
+// with the same key are merged first, which renders the same in a text handler.
+func sortAttrs(attrs []slog.Attr) []slog.Attr {
+	sorted := make([]slog.Attr, 0, len(attrs))
+	groups := make(map[string]int)
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if v.Kind() != slog.KindGroup {
+			sorted = append(sorted, a)
+			continue
+		}
+		if i, ok := groups[a.Key]; ok {
+			merged := append(sorted[i].Value.Group(), v.Group()...)
+			sorted[i].Value = slog.GroupValue(merged...)
+			continue
+		}
+		groups[a.Key] = len(sorted)
+		sorted = append(sorted, slog.Attr{Key: a.Key, Value: slog.GroupValue(slices.Clone(v.Group())...)})
+	}
+
+	for i, a := range sorted {
+		if a.Value.Kind() == slog.KindGroup {
+			sorted[i].Value = slog.GroupValue(sortAttrs(a.Value.Group())...)
+		}
+	}
+	slices.SortStableFunc(sorted, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return sorted
+}
+
+// lineDiff returns a minimal line diff of want and got, with unchanged lines
+// prefixed by two spaces and changed lines by "- " or "+ "
+func lineDiff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, "  %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,141 @@
+package loglatertest
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater"
+)
+
+// newGoldenCollector returns a collector with records whose times and request IDs
+// differ between runs
+func newGoldenCollector() *loglater.LogCollector {
+	collector := loglater.NewLogCollector(nil)
+	logger := slog.New(collector)
+	req := logger.With("request_id", time.Now().UnixNano()).WithGroup("api")
+
+	logger.Info("starting", "version", "1.2.3", "port", 8080)
+	req.Warn("slow request", "path", "/v1/items", "elapsed", 1500*time.Millisecond)
+	req.Error("request failed", "user", 123, "err", "timeout")
+	return collector
+}
+
+func TestGolden(t *testing.T) {
+	t.Run("matches", func(t *testing.T) {
+		Golden(t, newGoldenCollector(), "testdata/requests.golden", WithMask("request_id"))
+	})
+
+	t.Run("options", func(t *testing.T) {
+		Golden(t, newGoldenCollector(), "testdata/requests_sorted.golden",
+			WithMask("request_id", "api.elapsed"), WithSortedAttrs())
+	})
+
+	t.Run("differs", func(t *testing.T) {
+		collector := newGoldenCollector()
+		slog.New(collector).Info("unexpected")
+
+		tb := &fakeTB{}
+		Golden(tb, collector, "testdata/requests.golden", WithMask("request_id"))
+
+		want := `  level=WARN msg="slow request" request_id=<masked> api.path=/v1/items api.elapsed=1.5s
+  level=ERROR msg="request failed" request_id=<masked> api.user=123 api.err=timeout
++ level=INFO msg=unexpected`
+		if !strings.Contains(tb.fatal, want) || !strings.Contains(tb.fatal, UpdateEnv+"=1") {
+			t.Errorf("unexpected message:\n%s", tb.fatal)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		tb := &fakeTB{}
+		Golden(tb, newGoldenCollector(), filepath.Join(t.TempDir(), "missing.golden"))
+		if !strings.Contains(tb.fatal, "does not exist; run the test with "+UpdateEnv) {
+			t.Errorf("unexpected message %q", tb.fatal)
+		}
+	})
+
+	// update checks that Golden writes the expected file when updating
+	update := func(t *testing.T, opts ...GoldenOption) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "new", "dir", "out.golden")
+
+		tb := &fakeTB{}
+		Golden(tb, newGoldenCollector(), path, append(opts, WithMask("request_id"))...)
+		if tb.failed {
+			t.Fatalf("unexpected failure: %s", tb.fatal)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile("testdata/requests.golden")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+
+	t.Run("update from env", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "1")
+		update(t)
+	})
+
+	t.Run("update option", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "")
+		update(t, WithUpdate(true))
+	})
+
+	t.Run("no global flags", func(t *testing.T) {
+		// A test binary must be free to define its own -update flag
+		if flag.Lookup("update") != nil {
+			t.Error("expected no update flag to be registered")
+		}
+	})
+}
+
+func TestRenderGolden(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	collector := loglater.NewLogCollector(nil)
+	logger := slog.New(collector)
+	for i, d := range []time.Duration{0, 1500 * time.Millisecond, time.Minute} {
+		r := slog.NewRecord(start.Add(d), slog.LevelInfo, "tick", 0)
+		r.AddAttrs(slog.Int("n", i), slog.Group("b", slog.Int("z", 1), slog.Int("a", 2)))
+		_ = logger.Handler().Handle(t.Context(), r)
+	}
+
+	got := string(renderGolden(collector.GetLogs(), &goldenConfig{relativeTime: true, sortAttrs: true}))
+	want := `time=+0s level=INFO msg=tick b.a=2 b.z=1 n=0
+time=+1.5s level=INFO msg=tick b.a=2 b.z=1 n=1
+time=+1m0s level=INFO msg=tick b.a=2 b.z=1 n=2
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	cases := []struct {
+		name      string
+		want, got string
+		diff      string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", "  a\n  b"},
+		{"added", "a\n", "a\nb\n", "  a\n+ b"},
+		{"removed", "a\nb\nc\n", "a\nc\n", "  a\n- b\n  c"},
+		{"changed", "a\nb\nc\n", "a\nx\nc\n", "  a\n- b\n+ x\n  c"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := lineDiff(tc.want, tc.got); got != tc.diff {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package loglatertest captures logs in tests and shows them only for failing
+// tests, keeping passing runs quiet:
+//
+//	func TestServer(t *testing.T) {
+//		logger := loglatertest.New(t)
+//		srv := NewServer(logger)
+//		...
+//	}
+//
+// Every record is collected, including DEBUG. When the test fails, the records are
+// replayed through a text handler writing to t.Output() as the test finishes.
+package loglatertest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// Option configures how captured logs are collected and shown
+type Option func(*config)
+
+type config struct {
+	newHandler    func(io.Writer) slog.Handler
+	always        bool
+	collectorOpts []loglater.Option
+}
+
+// WithHandlerOptions sets the options of the text handler used to show the logs,
+// for example to add the source location. As with any text handler, a nil Level
+// hides DEBUG records.
+func WithHandlerOptions(opts *slog.HandlerOptions) Option {
+	return func(cfg *config) {
+		cfg.newHandler = func(w io.Writer) slog.Handler {
+			return slog.NewTextHandler(w, opts)
+		}
+	}
+}
+
+// WithHandler sets the handler used to show the logs, created with the test's
+// output writer, replacing the default text handler. Records below the handler's
+// level are not shown.
+//
+//	loglatertest.WithHandler(func(w io.Writer) slog.Handler {
+//		return slog.NewJSONHandler(w, nil)
+//	})
+func WithHandler(newHandler func(io.Writer) slog.Handler) Option {
+	return func(cfg *config) {
+		if newHandler != nil {
+			cfg.newHandler = newHandler
+		}
+	}
+}
+
+// WithAlways shows the logs when the test passes too.
+func WithAlways() Option {
+	return func(cfg *config) {
+		cfg.always = true
+	}
+}
+
+// WithCollectorOptions configures the underlying collector, for example with a
+// custom storage.
+func WithCollectorOptions(opts ...loglater.Option) Option {
+	return func(cfg *config) {
+		cfg.collectorOpts = append(cfg.collectorOpts, opts...)
+	}
+}
+
+// New returns a logger whose records are shown in the test output only if the test
+// fails.
+func New(t testing.TB, opts ...Option) *slog.Logger {
+	t.Helper()
+	return slog.New(NewCollector(t, opts...))
+}
+
+// NewCollector returns a collector whose records are shown in the test output only
+// if the test fails. Use it instead of New to also inspect the captured records.
+func NewCollector(t testing.TB, opts ...Option) *loglater.LogCollector {
+	t.Helper()
+	cfg := &config{
+		newHandler: func(w io.Writer) slog.Handler {
+			return slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+		},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	collector := loglater.NewLogCollector(nil, cfg.collectorOpts...)
+	t.Cleanup(func() {
+		if !t.Failed() && !cfg.always {
+			return
+		}
+		// Replay does not consult the handler's level, so check it here
+		handler := cfg.newHandler(t.Output())
+		enabled := loglater.WithFilter(func(r *storage.Record) bool {
+			return handler.Enabled(context.Background(), r.Level)
+		})
+		if err := collector.PlayLogs(handler, enabled); err != nil {
+			t.Logf("loglatertest: replaying captured logs: %v", err)
+		}
+	})
+	return collector
+}
@@ -0,0 +1,147 @@
+package loglatertest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// fakeTB records failures, cleanups and output without affecting the real test
+type fakeTB struct {
+	testing.TB
+	failed   bool
+	cleanups []func()
+	out      bytes.Buffer
+	logs     []string
+	fatal    string
+}
+
+func (f *fakeTB) Helper()           {}
+func (f *fakeTB) Failed() bool      { return f.failed }
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Output() io.Writer { return &f.out }
+func (f *fakeTB) Logf(format string, args ...any) {
+	f.logs = append(f.logs, format)
+}
+
+// Fatalf records the failure; unlike the real method it does not stop the caller
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failed = true
+	f.fatal = fmt.Sprintf(format, args...)
+}
+
+// finish runs the registered cleanups in reverse order, as the testing package does
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("passing test is quiet", func(t *testing.T) {
+		tb := &fakeTB{}
+		logger := New(tb)
+		logger.Info("hello")
+		tb.finish()
+
+		if tb.out.Len() != 0 {
+			t.Errorf("expected no output, got %q", tb.out.String())
+		}
+	})
+
+	t.Run("failing test shows logs", func(t *testing.T) {
+		tb := &fakeTB{}
+		logger := New(tb)
+		logger.Debug("details", "id", 7)
+		logger.WithGroup("req").Error("failed", "path", "/x")
+		tb.failed = true
+		tb.finish()
+
+		out := tb.out.String()
+		if !strings.Contains(out, "level=DEBUG msg=details id=7") {
+			t.Errorf("expected the debug record, got %q", out)
+		}
+		if !strings.Contains(out, "level=ERROR msg=failed req.path=/x") {
+			t.Errorf("expected the error record, got %q", out)
+		}
+	})
+
+	t.Run("always", func(t *testing.T) {
+		tb := &fakeTB{}
+		New(tb, WithAlways()).Info("hello")
+		tb.finish()
+
+		if !strings.Contains(tb.out.String(), "msg=hello") {
+			t.Errorf("expected output, got %q", tb.out.String())
+		}
+	})
+
+	t.Run("handler options", func(t *testing.T) {
+		tb := &fakeTB{failed: true}
+		logger := New(tb, WithHandlerOptions(&slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		logger.Debug("hidden")
+		logger.Info("shown")
+		tb.finish()
+
+		if got := tb.out.String(); got != "level=INFO msg=shown\n" {
+			t.Errorf("unexpected output %q", got)
+		}
+	})
+
+	t.Run("custom handler", func(t *testing.T) {
+		tb := &fakeTB{failed: true}
+		New(tb, WithHandler(func(w io.Writer) slog.Handler {
+			return slog.NewJSONHandler(w, nil)
+		})).Info("hello")
+		tb.finish()
+
+		if !strings.Contains(tb.out.String(), `"msg":"hello"`) {
+			t.Errorf("expected JSON output, got %q", tb.out.String())
+		}
+	})
+
+	t.Run("replay error is logged", func(t *testing.T) {
+		tb := &fakeTB{failed: true}
+		New(tb, WithHandler(func(w io.Writer) slog.Handler {
+			return slog.NewTextHandler(errWriter{}, nil)
+		})).Info("hello")
+		tb.finish()
+
+		if len(tb.logs) != 1 || !strings.Contains(tb.logs[0], "replaying captured logs") {
+			t.Errorf("expected the replay error to be logged, got %v", tb.logs)
+		}
+	})
+}
+
+func TestNewCollector(t *testing.T) {
+	tb := &fakeTB{}
+	collector := NewCollector(tb)
+	slog.New(collector).Info("inspect me")
+
+	if logs := collector.GetLogs(); len(logs) != 1 || logs[0].Message != "inspect me" {
+		t.Errorf("unexpected records %+v", logs)
+	}
+	tb.finish()
+}
+
+// TestNewWithRealT checks that the cleanup works with the testing package itself
+func TestNewWithRealT(t *testing.T) {
+	t.Run("passes", func(t *testing.T) {
+		New(t).Info("not shown")
+	})
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
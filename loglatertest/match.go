@@ -0,0 +1,176 @@
+package loglatertest
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// Matcher checks one property of a realized record, such as its level, message or
+// an attribute. Matchers given together must all match.
+type Matcher struct {
+	desc  string
+	check func(r *storage.Record) (ok bool, got string)
+}
+
+// String describes what the matcher expects
+func (m Matcher) String() string {
+	return m.desc
+}
+
+// Match reports whether the record matches
+func (m Matcher) Match(r *storage.Record) bool {
+	ok, _ := m.check(r)
+	return ok
+}
+
+// Level matches records at exactly the level
+func Level(level slog.Level) Matcher {
+	return Matcher{
+		desc: "level == " + level.String(),
+		check: func(r *storage.Record) (bool, string) {
+			return r.Level == level, "level " + r.Level.String()
+		},
+	}
+}
+
+// MinLevel matches records at or above the level
+func MinLevel(level slog.Level) Matcher {
+	return Matcher{
+		desc: "level >= " + level.String(),
+		check: func(r *storage.Record) (bool, string) {
+			return r.Level >= level, "level " + r.Level.String()
+		},
+	}
+}
+
+// Msg matches records with exactly the message
+func Msg(msg string) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("msg == %q", msg),
+		check: func(r *storage.Record) (bool, string) {
+			return r.Message == msg, fmt.Sprintf("msg %q", r.Message)
+		},
+	}
+}
+
+// MsgContains matches records whose message contains the text
+func MsgContains(text string) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("msg contains %q", text),
+		check: func(r *storage.Record) (bool, string) {
+			return strings.Contains(r.Message, text), fmt.Sprintf("msg %q", r.Message)
+		},
+	}
+}
+
+// Attr matches records with an attribute at the dotted path, such as "api.user",
+// that equals the value. Numbers compare by value across integer and floating
+// point kinds, so Attr("port", 8080) matches a uint16 attribute.
+func Attr(path string, value any) Matcher {
+	keys := strings.Split(path, ".")
+	want := slog.AnyValue(value).Resolve()
+	return Matcher{
+		desc: fmt.Sprintf("%s == %s", path, formatValue(want)),
+		check: func(r *storage.Record) (bool, string) {
+			got, ok := r.Find(keys...)
+			if !ok {
+				return false, path + " missing"
+			}
+			return valuesEqual(got, want), fmt.Sprintf("%s %s", path, formatValue(got))
+		},
+	}
+}
+
+// HasAttr matches records with an attribute at the dotted path, whatever its value
+func HasAttr(path string) Matcher {
+	keys := strings.Split(path, ".")
+	return Matcher{
+		desc: path + " present",
+		check: func(r *storage.Record) (bool, string) {
+			_, ok := r.Find(keys...)
+			return ok, path + " missing"
+		},
+	}
+}
+
+// Where matches records for which the function returns true, described by desc in
+// failure messages
+func Where(desc string, match func(*storage.Record) bool) Matcher {
+	return Matcher{
+		desc: desc,
+		check: func(r *storage.Record) (bool, string) {
+			return match(r), "not " + desc
+		},
+	}
+}
+
+// All combines matchers into one that matches when they all do, for use as a single
+// step of RequireSequence
+func All(matchers ...Matcher) Matcher {
+	descs := make([]string, len(matchers))
+	for i, m := range matchers {
+		descs[i] = m.desc
+	}
+	return Matcher{
+		desc: strings.Join(descs, ", "),
+		check: func(r *storage.Record) (bool, string) {
+			var got []string
+			for _, m := range matchers {
+				if ok, g := m.check(r); !ok {
+					got = append(got, g)
+				}
+			}
+			return len(got) == 0, strings.Join(got, ", ")
+		},
+	}
+}
+
+// valuesEqual compares two resolved values, treating numbers of different kinds as
+// equal when they hold the same number. Any values are compared deeply, as they may
+// not be comparable.
+func valuesEqual(got, want slog.Value) bool {
+	got = got.Resolve()
+	if got.Kind() == want.Kind() {
+		switch got.Kind() {
+		case slog.KindAny, slog.KindLogValuer:
+			return reflect.DeepEqual(got.Any(), want.Any())
+		case slog.KindGroup:
+			return slices.EqualFunc(got.Group(), want.Group(), func(g, w slog.Attr) bool {
+				return g.Key == w.Key && valuesEqual(g.Value, w.Value.Resolve())
+			})
+		default:
+			return got.Equal(want)
+		}
+	}
+	g, gok := numberOf(got)
+	w, wok := numberOf(want)
+	return gok && wok && g == w
+}
+
+// numberOf returns the value of a numeric slog value
+func numberOf(v slog.Value) (float64, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return float64(v.Int64()), true
+	case slog.KindUint64:
+		return float64(v.Uint64()), true
+	case slog.KindFloat64:
+		return v.Float64(), true
+	default:
+		return 0, false
+	}
+}
+
+// formatValue formats a value for failure messages, quoting strings
+func formatValue(v slog.Value) string {
+	v = v.Resolve()
+	if v.Kind() == slog.KindString {
+		return fmt.Sprintf("%q", v.String())
+	}
+	return v.String()
+}
@@ -0,0 +1,76 @@
+package loglatertest
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestMatchers(t *testing.T) {
+	record := &storage.Record{
+		Level:   slog.LevelWarn,
+		Message: "request failed",
+		Attrs: []slog.Attr{
+			slog.Group("api",
+				slog.Int("user", 123),
+				slog.Any("port", uint16(8080)),
+				slog.Float64("ratio", 0.5),
+				slog.String("path", "/v1"),
+			),
+			slog.Duration("elapsed", time.Second),
+			slog.Any("tags", []string{"x"}),
+			slog.Any("labels", map[string]int{"a": 1}),
+		},
+	}
+
+	cases := []struct {
+		name    string
+		matcher Matcher
+		want    bool
+		desc    string
+		got     string
+	}{
+		{"level", Level(slog.LevelWarn), true, "level == WARN", ""},
+		{"level mismatch", Level(slog.LevelError), false, "level == ERROR", "level WARN"},
+		{"min level", MinLevel(slog.LevelInfo), true, "level >= INFO", ""},
+		{"min level above", MinLevel(slog.LevelError), false, "level >= ERROR", "level WARN"},
+		{"msg", Msg("request failed"), true, `msg == "request failed"`, ""},
+		{"msg mismatch", Msg("failed"), false, `msg == "failed"`, `msg "request failed"`},
+		{"msg contains", MsgContains("failed"), true, `msg contains "failed"`, ""},
+		{"attr int", Attr("api.user", 123), true, "api.user == 123", ""},
+		{"attr int mismatch", Attr("api.user", 456), false, "api.user == 456", "api.user 123"},
+		{"attr uint against int", Attr("api.port", 8080), true, "api.port == 8080", ""},
+		{"attr float", Attr("api.ratio", 0.5), true, "api.ratio == 0.5", ""},
+		{"attr string", Attr("api.path", "/v1"), true, `api.path == "/v1"`, ""},
+		{"attr string is not a number", Attr("api.user", "123"), false, `api.user == "123"`, "api.user 123"},
+		{"attr duration", Attr("elapsed", time.Second), true, "elapsed == 1s", ""},
+		{"attr slice", Attr("tags", []string{"x"}), true, "tags == [x]", ""},
+		{"attr slice mismatch", Attr("tags", []string{"y"}), false, "tags == [y]", "tags [x]"},
+		{"attr map", Attr("labels", map[string]int{"a": 1}), true, "labels == map[a:1]", ""},
+		{"attr missing", Attr("api.missing", 1), false, "api.missing == 1", "api.missing missing"},
+		{"has attr", HasAttr("api.path"), true, "api.path present", ""},
+		{"has attr missing", HasAttr("path"), false, "path present", "path missing"},
+		{"where", Where("has attrs", func(r *storage.Record) bool { return len(r.Attrs) > 0 }), true, "has attrs", ""},
+		{"all", All(Level(slog.LevelWarn), Attr("api.user", 123)), true, "level == WARN, api.user == 123", ""},
+		{"all mismatch", All(Level(slog.LevelError), Msg("request failed"), Attr("api.user", 1)), false,
+			`level == ERROR, msg == "request failed", api.user == 1`, "level WARN, api.user 123"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.matcher.Match(record); got != tc.want {
+				t.Errorf("Match = %v, want %v", got, tc.want)
+			}
+			if got := tc.matcher.String(); got != tc.desc {
+				t.Errorf("String = %q, want %q", got, tc.desc)
+			}
+			if !tc.want {
+				if _, got := tc.matcher.check(record); got != tc.got {
+					t.Errorf("got = %q, want %q", got, tc.got)
+				}
+			}
+		})
+	}
+}
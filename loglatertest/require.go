@@ -0,0 +1,162 @@
+package loglatertest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// maxListed caps the number of records listed in a failure message
+const maxListed = 50
+
+// RequireLogged fails the test unless a collected record matches all the matchers,
+// and returns the first one that does:
+//
+//	loglatertest.RequireLogged(t, collector,
+//		loglatertest.Level(slog.LevelError),
+//		loglatertest.Msg("failed"),
+//		loglatertest.Attr("api.user", 123))
+func RequireLogged(t testing.TB, c *loglater.LogCollector, matchers ...Matcher) storage.Record {
+	t.Helper()
+	m := combine(matchers)
+	records := c.GetLogs()
+	for _, r := range records {
+		if m.Match(&r) {
+			return r
+		}
+	}
+	t.Fatalf("no record matched %s\n%s", m, describe(records, m))
+	return storage.Record{}
+}
+
+// RequireNotLogged fails the test if any collected record matches all the matchers
+func RequireNotLogged(t testing.TB, c *loglater.LogCollector, matchers ...Matcher) {
+	t.Helper()
+	m := combine(matchers)
+	if matched := filter(c.GetLogs(), m); len(matched) > 0 {
+		t.Fatalf("expected no record matching %s, found %d\n%s", m, len(matched), describe(matched, m))
+	}
+}
+
+// RequireCount fails the test unless exactly n collected records match all the
+// matchers, and returns them
+func RequireCount(t testing.TB, c *loglater.LogCollector, n int, matchers ...Matcher) []storage.Record {
+	t.Helper()
+	m := combine(matchers)
+	records := c.GetLogs()
+	matched := filter(records, m)
+	if len(matched) != n {
+		t.Fatalf("expected %d records matching %s, found %d\n%s", n, m, len(matched), describe(records, m))
+	}
+	return matched
+}
+
+// RequireSequence fails the test unless the collected records contain a match for
+// each step, in order. Other records may come before, between and after the
+// matches. Use All to combine several matchers into one step:
+//
+//	loglatertest.RequireSequence(t, collector,
+//		loglatertest.Msg("connecting"),
+//		loglatertest.All(loglatertest.Level(slog.LevelWarn), loglatertest.Msg("retrying")),
+//		loglatertest.Msg("connected"))
+func RequireSequence(t testing.TB, c *loglater.LogCollector, steps ...Matcher) {
+	t.Helper()
+	records := c.GetLogs()
+	step, start := 0, 0
+	for i := range records {
+		if step == len(steps) {
+			break
+		}
+		if steps[step].Match(&records[i]) {
+			step++
+			start = i + 1
+		}
+	}
+	if step == len(steps) {
+		return
+	}
+
+	var b strings.Builder
+	for i, s := range steps {
+		mark := "ok"
+		if i >= step {
+			mark = "--"
+		}
+		fmt.Fprintf(&b, "  %s step %d: %s\n", mark, i+1, s)
+	}
+	t.Fatalf("matched %d of %d steps; no record after the last match matched step %d\n%s%s",
+		step, len(steps), step+1, b.String(), describe(records[start:], steps[step]))
+}
+
+// combine merges the matchers of one assertion
+func combine(matchers []Matcher) Matcher {
+	if len(matchers) == 0 {
+		return Matcher{
+			desc:  "any record",
+			check: func(*storage.Record) (bool, string) { return true, "" },
+		}
+	}
+	if len(matchers) == 1 {
+		return matchers[0]
+	}
+	return All(matchers...)
+}
+
+// filter returns the records that match
+func filter(records []storage.Record, m Matcher) []storage.Record {
+	var matched []storage.Record
+	for _, r := range records {
+		if m.Match(&r) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// describe lists the records for a failure message, noting for each one whether it
+// matches and otherwise what it has instead
+func describe(records []storage.Record, m Matcher) string {
+	if len(records) == 0 {
+		return "no records"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "records (%d):\n", len(records))
+	shown := records
+	if len(shown) > maxListed {
+		shown = shown[len(shown)-maxListed:]
+		fmt.Fprintf(&b, "  ... %d earlier records omitted\n", len(records)-maxListed)
+	}
+	for _, r := range shown {
+		fmt.Fprintf(&b, "  #%d %s\n", r.Seq, formatRecord(&r))
+		if ok, got := m.check(&r); ok {
+			b.WriteString("      matches\n")
+		} else {
+			fmt.Fprintf(&b, "      got %s\n", got)
+		}
+	}
+	return b.String()
+}
+
+// formatRecord formats a realized record as text handler output without the time
+func formatRecord(r *storage.Record) string {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	record := slog.NewRecord(r.Time, r.Level, r.Message, 0)
+	record.AddAttrs(r.Attrs...)
+	_ = h.Handle(context.Background(), record)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
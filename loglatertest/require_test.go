@@ -0,0 +1,167 @@
+package loglatertest
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater"
+)
+
+// newTestCollector returns a collector holding a short connection log
+func newTestCollector() *loglater.LogCollector {
+	collector := loglater.NewLogCollector(nil)
+	logger := slog.New(collector)
+	logger.Info("connecting", "host", "db")
+	logger.Warn("retrying", "attempt", 1)
+	logger.Warn("retrying", "attempt", 2)
+	logger.WithGroup("api").Error("failed", "user", 123)
+	logger.Info("connected")
+	return collector
+}
+
+func TestRequireLogged(t *testing.T) {
+	c := newTestCollector()
+
+	t.Run("found", func(t *testing.T) {
+		tb := &fakeTB{}
+		r := RequireLogged(tb, c, Level(slog.LevelError), Msg("failed"), Attr("api.user", 123))
+		if tb.failed || r.Seq != 4 {
+			t.Errorf("failed = %v (%s), seq = %d", tb.failed, tb.fatal, r.Seq)
+		}
+	})
+
+	t.Run("first match", func(t *testing.T) {
+		r := RequireLogged(&fakeTB{}, c, Msg("retrying"))
+		if v, _ := r.Find("attempt"); v.Int64() != 1 {
+			t.Errorf("expected the first retry, got %v", r)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tb := &fakeTB{}
+		RequireLogged(tb, c, Level(slog.LevelError), Attr("api.user", 456))
+
+		want := `no record matched level == ERROR, api.user == 456
+records (5):
+  #1 level=INFO msg=connecting host=db
+      got level INFO, api.user missing
+  #2 level=WARN msg=retrying attempt=1
+      got level WARN, api.user missing
+  #3 level=WARN msg=retrying attempt=2
+      got level WARN, api.user missing
+  #4 level=ERROR msg=failed api.user=123
+      got api.user 123
+  #5 level=INFO msg=connected
+      got level INFO, api.user missing
+`
+		if tb.fatal != want {
+			t.Errorf("got:\n%s\nwant:\n%s", tb.fatal, want)
+		}
+	})
+
+	t.Run("empty collector", func(t *testing.T) {
+		tb := &fakeTB{}
+		RequireLogged(tb, loglater.NewLogCollector(nil))
+		if tb.fatal != "no record matched any record\nno records" {
+			t.Errorf("unexpected message %q", tb.fatal)
+		}
+	})
+
+	t.Run("long logs are truncated", func(t *testing.T) {
+		c := loglater.NewLogCollector(nil)
+		logger := slog.New(c)
+		for i := range maxListed + 10 {
+			logger.Info(fmt.Sprint("record ", i))
+		}
+
+		tb := &fakeTB{}
+		RequireLogged(tb, c, Msg("missing"))
+		if !strings.Contains(tb.fatal, "records (60):\n  ... 10 earlier records omitted\n  #11 ") {
+			t.Errorf("unexpected message:\n%s", tb.fatal)
+		}
+	})
+}
+
+func TestRequireNotLogged(t *testing.T) {
+	c := newTestCollector()
+
+	tb := &fakeTB{}
+	RequireNotLogged(tb, c, Level(slog.LevelError), Attr("api.user", 456))
+	if tb.failed {
+		t.Errorf("unexpected failure: %s", tb.fatal)
+	}
+
+	RequireNotLogged(tb, c, Msg("retrying"))
+	want := `expected no record matching msg == "retrying", found 2
+records (2):
+  #2 level=WARN msg=retrying attempt=1
+      matches
+  #3 level=WARN msg=retrying attempt=2
+      matches
+`
+	if tb.fatal != want {
+		t.Errorf("got:\n%s\nwant:\n%s", tb.fatal, want)
+	}
+}
+
+func TestRequireCount(t *testing.T) {
+	c := newTestCollector()
+
+	tb := &fakeTB{}
+	if got := RequireCount(tb, c, 2, Level(slog.LevelWarn)); len(got) != 2 || tb.failed {
+		t.Errorf("got %d records, failure %q", len(got), tb.fatal)
+	}
+	if RequireCount(tb, c, 0, Msg("missing")); tb.failed {
+		t.Errorf("unexpected failure: %s", tb.fatal)
+	}
+	if RequireCount(tb, c, 5); tb.failed {
+		t.Errorf("unexpected failure: %s", tb.fatal)
+	}
+
+	RequireCount(tb, c, 1, Msg("retrying"))
+	if !strings.HasPrefix(tb.fatal, "expected 1 records matching msg == \"retrying\", found 2\nrecords (5):\n") {
+		t.Errorf("unexpected message:\n%s", tb.fatal)
+	}
+}
+
+func TestRequireSequence(t *testing.T) {
+	c := newTestCollector()
+
+	t.Run("in order", func(t *testing.T) {
+		tb := &fakeTB{}
+		RequireSequence(tb, c,
+			Msg("connecting"),
+			All(Level(slog.LevelWarn), Attr("attempt", 2)),
+			Msg("connected"))
+		if tb.failed {
+			t.Errorf("unexpected failure: %s", tb.fatal)
+		}
+	})
+
+	t.Run("no steps", func(t *testing.T) {
+		tb := &fakeTB{}
+		RequireSequence(tb, c)
+		if tb.failed {
+			t.Errorf("unexpected failure: %s", tb.fatal)
+		}
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		tb := &fakeTB{}
+		RequireSequence(tb, c, Msg("connecting"), Msg("failed"), Attr("attempt", 1))
+
+		want := `matched 2 of 3 steps; no record after the last match matched step 3
+  ok step 1: msg == "connecting"
+  ok step 2: msg == "failed"
+  -- step 3: attempt == 1
+records (1):
+  #5 level=INFO msg=connected
+      got attempt missing
+`
+		if tb.fatal != want {
+			t.Errorf("got:\n%s\nwant:\n%s", tb.fatal, want)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package loglater
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// StorageLookup finds log records by the value of an attribute path
+type StorageLookup interface {
+	Lookup(path string, value any) []storage.Record
+}
+
+// Lookup returns the realized records whose attribute at the dotted path, such as
+// "http.request_id", has the given value. Values are compared by their text.
+//
+// When the storage implements StorageLookup, such as a storage.MemStorage created
+// with storage.WithIndex, the lookup uses its index; otherwise every record is scanned.
+func (c *LogCollector) Lookup(path string, value any) []storage.Record {
+	if sl, ok := c.store.(StorageLookup); ok {
+		return realizeAll(sl.Lookup(path, value))
+	}
+
+	want := slog.AnyValue(value).Resolve().String()
+	parts := strings.Split(path, ".")
+	result := make([]storage.Record, 0)
+	for _, record := range c.GetLogs() {
+		if v, ok := record.Find(parts...); ok && v.String() == want {
+			result = append(result, record)
+		}
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package loglater
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestLookup(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		store Storage
+	}{
+		{"Indexed", storage.NewRecordStorage(storage.WithIndex("http.request_id"))},
+		{"Scan", &sliceStorage{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			collector := NewLogCollector(nil, WithStorage(tc.store))
+			logger := slog.New(collector).WithGroup("http")
+			logger.Info("first", "request_id", "abc")
+			logger.Info("other", "request_id", "def")
+			logger.Info("second", "request_id", "abc")
+
+			logs := collector.Lookup("http.request_id", "abc")
+			if len(logs) != 2 || logs[0].Message != "first" || logs[1].Message != "second" {
+				t.Fatalf("Expected first and second, got %v", logs)
+			}
+			if _, ok := logs[0].Find("http", "request_id"); !ok {
+				t.Error("Expected realized records from Lookup")
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package loglater
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// WithName names the collector, so records from a Merge of several collectors can be
+// tagged with their source
+func WithName(name string) Option {
+	return func(lc *LogCollector) {
+		lc.name = name
+	}
+}
+
+// Name returns the name given with WithName
+func (c *LogCollector) Name() string {
+	return c.name
+}
+
+// Merged is a read-only view of several collectors that interleaves their records by
+// time, created by Merge. It reads the collectors each time it is used, so it sees
+// records logged after it was created.
+type Merged struct {
+	sources   []*LogCollector
+	sourceKey string
+}
+
+// Merge returns a view of the collectors' records as one chronological stream,
+// ordered by time and then by sequence number, for example to combine per-request
+// collectors with a global one:
+//
+//	merged := loglater.Merge(global, request).WithSourceKey("source")
+//	merged.PlayLogs(handler)
+//	export.WriteNDJSON(os.Stdout, merged.All())
+//
+// Records keep the sequence numbers of their own collector, so the numbers of
+// records from different collectors may repeat.
+func Merge(collectors ...*LogCollector) *Merged {
+	return &Merged{sources: collectors}
+}
+
+// WithSourceKey returns a copy of the view that tags each record with the name of
+// its collector, given with WithName, as a top-level attribute with the key. Records
+// of unnamed collectors are not tagged.
+func (m *Merged) WithSourceKey(key string) *Merged {
+	tagged := *m
+	tagged.sourceKey = key
+	return &tagged
+}
+
+// All returns an iterator over the realized records of all collectors in order
+func (m *Merged) All() iter.Seq[storage.Record] {
+	return func(yield func(storage.Record) bool) {
+		for record := range m.records() {
+			if !yield(record.Realize()) {
+				return
+			}
+		}
+	}
+}
+
+// GetLogs returns the realized records of all collectors in order
+func (m *Merged) GetLogs() []storage.Record {
+	return slices.Collect(m.All())
+}
+
+// PlayLogs outputs the records of all collectors in order to the handler using a
+// background context
+func (m *Merged) PlayLogs(handler slog.Handler, opts ...ReplayOption) error {
+	return m.PlayLogsCtx(context.Background(), handler, opts...)
+}
+
+// PlayLogsCtx outputs the records of all collectors in order to the handler
+func (m *Merged) PlayLogsCtx(ctx context.Context, handler slog.Handler, opts ...ReplayOption) error {
+	if handler == nil {
+		return errors.New("handler is nil")
+	}
+
+	return newReplayConfig(opts).replay(ctx, handler, slices.Collect(m.records()), nil)
+}
+
+// records merges the raw records of the collectors, tagging them with their source
+func (m *Merged) records() iter.Seq[storage.Record] {
+	return func(yield func(storage.Record) bool) {
+		streams := make(mergeHeap, 0, len(m.sources))
+		for i, c := range m.sources {
+			records := c.store.GetAll()
+			if len(records) == 0 {
+				continue
+			}
+			// Records are stored in the order they were handled, which can differ
+			// slightly from the order of their times when logged concurrently
+			if !slices.IsSortedFunc(records, compareRecords) {
+				slices.SortStableFunc(records, compareRecords)
+			}
+			streams = append(streams, &mergeStream{records: records, source: i})
+		}
+		heap.Init(&streams)
+
+		for len(streams) > 0 {
+			s := streams[0]
+			record := s.records[0]
+			if name := m.sources[s.source].name; m.sourceKey != "" && name != "" {
+				prependAttrs(&record, slog.String(m.sourceKey, name))
+			}
+
+			s.records = s.records[1:]
+			if len(s.records) == 0 {
+				heap.Pop(&streams)
+			} else {
+				heap.Fix(&streams, 0)
+			}
+
+			if !yield(record) {
+				return
+			}
+		}
+	}
+}
+
+// compareRecords orders records by time, then by sequence number
+func compareRecords(a, b storage.Record) int {
+	if c := a.Time.Compare(b.Time); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Seq, b.Seq)
+}
+
+// mergeStream is the remaining records of one collector
+type mergeStream struct {
+	records []storage.Record
+	source  int // index of the collector, which breaks ties between equal records
+}
+
+// mergeHeap orders streams by their next record
+type mergeHeap []*mergeStream
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if c := compareRecords(h[i].records[0], h[j].records[0]); c != 0 {
+		return c < 0
+	}
+	return h[i].source < h[j].source
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(*mergeStream)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
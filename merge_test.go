@@ -0,0 +1,120 @@
+package loglater
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	logAt := func(c *LogCollector, offset time.Duration, msg string) {
+		t.Helper()
+		if err := c.Handle(t.Context(), slog.NewRecord(base.Add(offset), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messages := func(m *Merged) string {
+		var got []string
+		for r := range m.All() {
+			got = append(got, r.Message)
+		}
+		return strings.Join(got, ",")
+	}
+
+	setup := func() (*LogCollector, *LogCollector) {
+		global := NewLogCollector(nil, WithName("global"))
+		request := NewLogCollector(nil, WithName("request"))
+		logAt(global, 0, "g1")
+		logAt(request, time.Second, "r1")
+		logAt(global, 2*time.Second, "g2")
+		logAt(request, 2*time.Second, "r2")
+		logAt(request, 3*time.Second, "r3")
+		return global, request
+	}
+
+	t.Run("OrdersByTime", func(t *testing.T) {
+		global, request := setup()
+		if got := messages(Merge(global, request)); got != "g1,r1,g2,r2,r3" {
+			t.Errorf("Expected g1,r1,g2,r2,r3, got %s", got)
+		}
+		if got := messages(Merge(request, global)); got != "g1,r1,r2,g2,r3" {
+			t.Errorf("Expected ties broken by collector order, got %s", got)
+		}
+	})
+
+	t.Run("SortsOutOfOrderRecords", func(t *testing.T) {
+		c := NewLogCollector(nil)
+		logAt(c, 2*time.Second, "b")
+		logAt(c, time.Second, "a")
+		if got := messages(Merge(c)); got != "a,b" {
+			t.Errorf("Expected a,b, got %s", got)
+		}
+	})
+
+	t.Run("SeesNewRecords", func(t *testing.T) {
+		global, request := setup()
+		merged := Merge(global, request)
+		logAt(global, 4*time.Second, "g3")
+		if logs := merged.GetLogs(); len(logs) != 6 || logs[5].Message != "g3" {
+			t.Errorf("Expected the new record last, got %d records", len(logs))
+		}
+	})
+
+	t.Run("SourceKey", func(t *testing.T) {
+		global, request := setup()
+		unnamed := NewLogCollector(nil)
+		logAt(unnamed, 5*time.Second, "u1")
+
+		logs := Merge(global, request, unnamed).WithSourceKey("source").GetLogs()
+		if v, _ := logs[0].Find("source"); v.String() != "global" {
+			t.Errorf("Expected source global, got %q", v.String())
+		}
+		if v, _ := logs[1].Find("source"); v.String() != "request" {
+			t.Errorf("Expected source request, got %q", v.String())
+		}
+		if _, ok := logs[5].Find("source"); ok {
+			t.Error("Expected records of unnamed collectors not to be tagged")
+		}
+		if _, ok := Merge(global).GetLogs()[0].Find("source"); ok {
+			t.Error("Expected no tag without a source key")
+		}
+	})
+
+	t.Run("PlayLogs", func(t *testing.T) {
+		global, request := setup()
+		slog.New(global).WithGroup("api").Info("g3", "user", "bob")
+
+		var buf bytes.Buffer
+		merged := Merge(global, request).WithSourceKey("source")
+		if err := merged.PlayLogs(slog.NewTextHandler(&buf, nil)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 6 {
+			t.Fatalf("Expected 6 records, got %d", len(lines))
+		}
+		if !strings.Contains(lines[5], "source=global api.user=bob") {
+			t.Errorf("Expected the source outside the record's groups, got %s", lines[5])
+		}
+
+		handler := &failOnMessageHandler{fail: "g2"}
+		if err := merged.PlayLogs(handler); err == nil {
+			t.Error("Expected the handler error")
+		}
+		if got := strings.Join(handler.handled, ","); got != "g1,r1" {
+			t.Errorf("Expected replay to stop at the failure, got %s", got)
+		}
+		if err := merged.PlayLogs(nil); err == nil {
+			t.Error("Expected an error for a nil handler")
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if logs := Merge().GetLogs(); len(logs) != 0 {
+			t.Errorf("Expected no records, got %d", len(logs))
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package loglater
+
+import (
+	"context"
+	"time"
+)
+
+// WithPacing replays records with the gaps between their original times, divided by
+// speed: 1 replays in real time and 10 ten times faster. Gaps longer than maxGap
+// after scaling are shortened to maxGap, unless it is zero. The wait between records
+// stops early when the context is canceled.
+//
+// Records whose time is zero or earlier than a record before are replayed without
+// waiting, and the gap to the next record is measured from the latest time seen.
+// A speed of zero or less replays without pacing.
+func WithPacing(speed float64, maxGap time.Duration) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.speed = speed
+		cfg.maxGap = maxGap
+	}
+}
+
+// pace waits for the scaled gap between the previous record's time and t
+func (cfg *replayConfig) pace(ctx context.Context, t time.Time) error {
+	if cfg.speed <= 0 || t.IsZero() {
+		return nil
+	}
+	prev := cfg.lastTime
+	if !t.After(prev) {
+		// Keep the latest time, so an earlier record does not stretch the next gap
+		return nil
+	}
+	cfg.lastTime = t
+	if prev.IsZero() {
+		return nil
+	}
+
+	gap := time.Duration(float64(t.Sub(prev)) / cfg.speed)
+	if cfg.maxGap > 0 {
+		gap = min(gap, cfg.maxGap)
+	}
+	if gap <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(gap)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
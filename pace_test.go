@@ -0,0 +1,117 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// timingHandler records the time at which each record is handled
+type timingHandler struct {
+	times []time.Time
+}
+
+func (h *timingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *timingHandler) Handle(context.Context, slog.Record) error {
+	h.times = append(h.times, time.Now())
+	return nil
+}
+func (h *timingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *timingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestWithPacing(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	setup := func(t *testing.T, offsets ...time.Duration) *LogCollector {
+		collector := NewLogCollector(nil)
+		for _, offset := range offsets {
+			r := slog.NewRecord(base.Add(offset), slog.LevelInfo, "tick", 0)
+			if err := collector.Handle(t.Context(), r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return collector
+	}
+
+	// gaps returns the time between consecutive handled records
+	gaps := func(times []time.Time) []time.Duration {
+		var result []time.Duration
+		for i := 1; i < len(times); i++ {
+			result = append(result, times[i].Sub(times[i-1]))
+		}
+		return result
+	}
+
+	t.Run("ScalesAndCapsGaps", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup(t, 0, time.Second, 3*time.Second, 63*time.Second, 62*time.Second)
+			handler := &timingHandler{}
+			if err := collector.PlayLogs(handler, WithPacing(2, 10*time.Second)); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+
+			want := []time.Duration{500 * time.Millisecond, time.Second, 10 * time.Second, 0}
+			got := gaps(handler.times)
+			if len(got) != len(want) {
+				t.Fatalf("Expected %d gaps, got %v", len(want), got)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("Gap %d: expected %v, got %v", i, want[i], got[i])
+				}
+			}
+		})
+	})
+
+	t.Run("EarlierRecordDoesNotStretchGap", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup(t, 0, 10*time.Second, 2*time.Second, 11*time.Second)
+			handler := &timingHandler{}
+			if err := collector.PlayLogs(handler, WithPacing(1, 0)); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+
+			want := []time.Duration{10 * time.Second, 0, time.Second}
+			if got := gaps(handler.times); !slices.Equal(got, want) {
+				t.Errorf("Expected gaps %v, got %v", want, got)
+			}
+		})
+	})
+
+	t.Run("NoPacingByDefault", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup(t, 0, time.Hour)
+			handler := &timingHandler{}
+			if err := collector.PlayLogs(handler); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+			if got := gaps(handler.times); got[0] != 0 {
+				t.Errorf("Expected no wait, got %v", got[0])
+			}
+		})
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup(t, 0, time.Hour)
+			ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+			defer cancel()
+
+			handler := &timingHandler{}
+			start := time.Now()
+			err := collector.PlayLogsCtx(ctx, handler, WithPacing(1, 0))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected deadline exceeded, got %v", err)
+			}
+			if len(handler.times) != 1 {
+				t.Errorf("Expected only the first record, got %d", len(handler.times))
+			}
+			if waited := time.Since(start); waited != time.Minute {
+				t.Errorf("Expected to stop waiting at the deadline, waited %v", waited)
+			}
+		})
+	})
+}
@@ -0,0 +1,207 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// WithParallel hands records to the handler from the number of workers at once, for
+// handlers that are slow per record, such as ones that send over the network. The
+// handler must be safe for concurrent use.
+//
+// Records are handed out in order but may be handled out of order; use WithOrderKey
+// to keep related records in order. Replay continues past handler errors and
+// returns them all joined; Drain removes only the records that were handled
+// successfully. PlayFrom always replays sequentially.
+func WithParallel(workers int) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.workers = workers
+	}
+}
+
+// WithOrderKey keeps records with the same value of the attribute at the dotted path,
+// such as "request_id", in order when replaying with WithParallel, by handing them
+// all to the same worker. The path is that of the realized record, so an attribute
+// logged after WithGroup("req") is at "req.request_id". Records without the
+// attribute may go to any worker.
+func WithOrderKey(path string) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.orderKey = strings.Split(path, ".")
+	}
+}
+
+// WithProgress calls report after each record is replayed, fails or is skipped by a
+// filter, with the number of records done and the total. Calls are never concurrent.
+func WithProgress(report func(done, total int)) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.progress = report
+	}
+}
+
+// replay hands the stored records that pass the filter to the handler, calling
+// handled with the sequence number of each record the handler accepted. Sequential
+// replay stops at the first error.
+func (cfg *replayConfig) replay(ctx context.Context, handler slog.Handler, records []storage.Record, handled func(seq uint64)) error {
+	if cfg.workers > 1 {
+		return cfg.replayParallel(ctx, handler, records, handled)
+	}
+
+	for i := range records {
+		stored := &records[i]
+		if !cfg.match(stored) {
+			cfg.report(len(records))
+			continue
+		}
+		err := cfg.play(ctx, handler, stored)
+		cfg.report(len(records))
+		if err != nil {
+			return err
+		}
+		if handled != nil {
+			handled(stored.Seq)
+		}
+	}
+	return nil
+}
+
+// report counts a finished record and reports progress, if configured
+func (cfg *replayConfig) report(total int) {
+	if cfg.progress == nil {
+		return
+	}
+	cfg.progressMu.Lock()
+	defer cfg.progressMu.Unlock()
+	cfg.done++
+	cfg.progress(cfg.done, total)
+}
+
+// replayJob is a stored record prepared for a worker
+type replayJob struct {
+	ctx     context.Context
+	seq     uint64
+	records []storage.Record
+}
+
+// replayParallel replays with a pool of workers. Records are filtered, prepared and
+// scheduled in order by the caller's goroutine, so pacing and relative times behave
+// as in sequential replay.
+func (cfg *replayConfig) replayParallel(ctx context.Context, handler slog.Handler, records []storage.Record, handled func(seq uint64)) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+
+	run := func(job replayJob) {
+		var err error
+		for i := range job.records {
+			if err = playRecord(job.ctx, handler, &job.records[i]); err != nil {
+				break
+			}
+		}
+
+		mu.Lock()
+		switch {
+		case err == nil:
+			if handled != nil {
+				handled(job.seq)
+			}
+		case ctx.Err() == nil || !errors.Is(err, ctx.Err()):
+			// cancellation is reported once, below
+			errs = append(errs, err)
+		}
+		mu.Unlock()
+		cfg.report(len(records))
+	}
+
+	// Records with an order key go to the worker chosen by the key, others to any
+	shared := make(chan replayJob)
+	keyed := make([]chan replayJob, cfg.workers)
+	for i := range keyed {
+		keyed[i] = make(chan replayJob)
+		own, anyQueue := keyed[i], shared
+		wg.Go(func() {
+			for own != nil || anyQueue != nil {
+				select {
+				case job, ok := <-own:
+					if !ok {
+						own = nil
+						continue
+					}
+					run(job)
+				case job, ok := <-anyQueue:
+					if !ok {
+						anyQueue = nil
+						continue
+					}
+					run(job)
+				}
+			}
+		})
+	}
+
+dispatch:
+	for i := range records {
+		stored := &records[i]
+		if ctx.Err() != nil {
+			break
+		}
+		if !cfg.match(stored) {
+			cfg.report(len(records))
+			continue
+		}
+
+		jobCtx, prepared := cfg.prepare(ctx, stored)
+		for j := range prepared {
+			if err := cfg.schedule(ctx, &prepared[j]); err != nil {
+				break dispatch
+			}
+		}
+
+		queue := shared
+		if key, ok := cfg.orderValue(stored); ok {
+			queue = keyed[workerFor(key, cfg.workers)]
+		}
+		select {
+		case queue <- replayJob{ctx: jobCtx, seq: stored.Seq, records: prepared}:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+
+	close(shared)
+	for _, ch := range keyed {
+		close(ch)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// orderValue returns the value of the order key attribute of a stored record
+func (cfg *replayConfig) orderValue(stored *storage.Record) (string, bool) {
+	if len(cfg.orderKey) == 0 {
+		return "", false
+	}
+	v, ok := stored.FindRealized(cfg.orderKey...)
+	if !ok {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// workerFor returns the worker that handles the records with the key
+func workerFor(key string, workers int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
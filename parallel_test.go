@@ -0,0 +1,208 @@
+package loglater
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// slowHandler sleeps for the record's "delay" attribute, then records its message.
+// Records with the message in fail return an error.
+type slowHandler struct {
+	mu      sync.Mutex
+	fail    []string
+	handled []string
+}
+
+func (h *slowHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *slowHandler) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "delay" {
+			time.Sleep(a.Value.Duration())
+		}
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if slices.Contains(h.fail, r.Message) {
+		return errHandlerFailed
+	}
+	h.handled = append(h.handled, r.Message)
+	return nil
+}
+func (h *slowHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *slowHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestWithParallel(t *testing.T) {
+	setup := func(messages ...string) *LogCollector {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		for _, msg := range messages {
+			logger.Info(msg, "delay", 100*time.Millisecond)
+		}
+		return collector
+	}
+
+	t.Run("HandlesConcurrently", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup("a", "b", "c", "d", "e", "f", "g", "h")
+			handler := &slowHandler{}
+
+			start := time.Now()
+			if err := collector.PlayLogs(handler, WithParallel(4)); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed != 200*time.Millisecond {
+				t.Errorf("Expected 8 records on 4 workers to take 200ms, took %v", elapsed)
+			}
+			if len(handler.handled) != 8 {
+				t.Errorf("Expected 8 records handled, got %v", handler.handled)
+			}
+		})
+	})
+
+	t.Run("OrderKey", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := NewLogCollector(nil)
+			logger := slog.New(collector)
+			// Later records of each request are quicker, so they would overtake
+			for _, id := range []string{"r1", "r2", "r3"} {
+				for i, delay := range []time.Duration{300, 200, 100} {
+					logger.With("request_id", id).Info(id+"-"+string(rune('1'+i)), "delay", delay*time.Millisecond)
+				}
+			}
+			logger.Info("unkeyed")
+
+			handler := &slowHandler{}
+			if err := collector.PlayLogs(handler, WithParallel(3), WithOrderKey("request_id")); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+
+			for _, id := range []string{"r1", "r2", "r3"} {
+				var got []string
+				for _, msg := range handler.handled {
+					if msg[:2] == id {
+						got = append(got, msg)
+					}
+				}
+				expected := []string{id + "-1", id + "-2", id + "-3"}
+				if !slices.Equal(got, expected) {
+					t.Errorf("Expected %v in order, got %v", expected, got)
+				}
+			}
+			if !slices.Contains(handler.handled, "unkeyed") {
+				t.Errorf("Expected the unkeyed record handled, got %v", handler.handled)
+			}
+		})
+	})
+
+	t.Run("OrderKeyInGroup", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.WithGroup("req").Info("grouped", "request_id", "r1")
+		logger.Info("top", "request_id", "r2")
+		records := collector.store.GetAll()
+
+		tests := []struct {
+			path     string
+			expected []string
+		}{
+			{"request_id", []string{"", "r2"}},
+			{"req.request_id", []string{"r1", ""}},
+		}
+		for _, tt := range tests {
+			cfg := newReplayConfig([]ReplayOption{WithOrderKey(tt.path)})
+			for i := range records {
+				got, _ := cfg.orderValue(&records[i])
+				if got != tt.expected[i] {
+					t.Errorf("Expected %q at %q of record %q, got %q", tt.expected[i], tt.path, records[i].Message, got)
+				}
+			}
+		}
+	})
+
+	t.Run("AggregatesErrors", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup("a", "b", "c", "d")
+			handler := &slowHandler{fail: []string{"b", "d"}}
+
+			err := collector.PlayLogs(handler, WithParallel(2))
+			if !errors.Is(err, errHandlerFailed) {
+				t.Fatalf("Expected errHandlerFailed, got %v", err)
+			}
+			if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 2 {
+				t.Errorf("Expected both errors joined, got %v", err)
+			}
+			slices.Sort(handler.handled)
+			if !slices.Equal(handler.handled, []string{"a", "c"}) {
+				t.Errorf("Expected replay to continue past errors, got %v", handler.handled)
+			}
+		})
+	})
+
+	t.Run("DrainKeepsFailed", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			collector := setup("a", "b", "c", "d")
+			handler := &slowHandler{fail: []string{"b"}}
+
+			if err := collector.Drain(t.Context(), handler, WithParallel(2)); !errors.Is(err, errHandlerFailed) {
+				t.Fatalf("Expected errHandlerFailed, got %v", err)
+			}
+			remaining := collector.GetLogs()
+			if len(remaining) != 1 || remaining[0].Message != "b" {
+				t.Errorf("Expected only b to remain, got %v", remaining)
+			}
+		})
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		collector := setup("a", "b")
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		err := collector.PlayLogsCtx(ctx, &slowHandler{}, WithParallel(2))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestWithProgress(t *testing.T) {
+	collector := NewLogCollector(nil)
+	logger := slog.New(collector)
+	logger.Info("a")
+	logger.Debug("b")
+	logger.Info("c")
+
+	keepInfo := WithFilter(func(r *storage.Record) bool { return r.Level == slog.LevelInfo })
+	for _, tt := range []struct {
+		name string
+		opts []ReplayOption
+	}{
+		{"Sequential", nil},
+		{"Parallel", []ReplayOption{WithParallel(2)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports [][2]int
+			opts := append(tt.opts, keepInfo, WithProgress(func(done, total int) {
+				reports = append(reports, [2]int{done, total})
+			}))
+			if err := collector.PlayLogs(&slowHandler{}, opts...); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+
+			expected := [][2]int{{1, 3}, {2, 3}, {3, 3}}
+			if !slices.Equal(reports, expected) {
+				t.Errorf("Expected reports %v, got %v", expected, reports)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package query
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// operator is a comparison operator
+type operator string
+
+const (
+	opEq       operator = "=="
+	opNe       operator = "!="
+	opLt       operator = "<"
+	opLe       operator = "<="
+	opGt       operator = ">"
+	opGe       operator = ">="
+	opMatch    operator = "~"
+	opNotMatch operator = "!~"
+)
+
+// isRegex reports whether the operator matches a regular expression
+func (op operator) isRegex() bool {
+	return op == opMatch || op == opNotMatch
+}
+
+// apply interprets the result of a three-way comparison for the operator
+func (op operator) apply(c int) bool {
+	switch op {
+	case opEq:
+		return c == 0
+	case opNe:
+		return c != 0
+	case opLt:
+		return c < 0
+	case opLe:
+		return c <= 0
+	case opGt:
+		return c > 0
+	case opGe:
+		return c >= 0
+	default:
+		return false
+	}
+}
+
+// fieldKind identifies a built-in record field or an attribute
+type fieldKind int
+
+const (
+	fieldAttr fieldKind = iota
+	fieldLevel
+	fieldMsg
+	fieldTime
+	fieldSeq
+)
+
+// field is the left-hand side of a comparison
+type field struct {
+	kind fieldKind
+	path []string // attribute path for fieldAttr
+}
+
+func (f field) name() string {
+	switch f.kind {
+	case fieldLevel:
+		return "level"
+	case fieldMsg:
+		return "msg"
+	case fieldTime:
+		return "time"
+	case fieldSeq:
+		return "seq"
+	default:
+		return strings.Join(f.path, ".")
+	}
+}
+
+// literal is the right-hand side of a comparison, pre-parsed into every
+// interpretation that the value supports.
+type literal struct {
+	raw    string
+	quoted bool
+
+	re *regexp.Regexp
+
+	isInt   bool
+	intVal  int64
+	isNum   bool
+	numVal  float64
+	isBool  bool
+	boolVal bool
+	isLevel bool
+	level   slog.Level
+	isDur   bool
+	dur     time.Duration
+
+	// times are either absolute, or relative to the time of evaluation
+	isTime  bool
+	timeVal time.Time
+	fromNow bool
+	offset  time.Duration
+}
+
+// newLiteral parses the value token for a comparison against the field,
+// rejecting values that can never be compared with a built-in field.
+func newLiteral(f field, op operator, tok token) (literal, error) {
+	lit := literal{raw: tok.val, quoted: tok.typ == tokString}
+
+	if op.isRegex() {
+		re, err := regexp.Compile(tok.val)
+		if err != nil {
+			return lit, fmt.Errorf("invalid regular expression %q: %w", tok.val, err)
+		}
+		lit.re = re
+		return lit, nil
+	}
+
+	if i, err := strconv.ParseInt(tok.val, 10, 64); err == nil {
+		lit.isInt, lit.intVal = true, i
+	}
+	if n, err := strconv.ParseFloat(tok.val, 64); err == nil {
+		lit.isNum, lit.numVal = true, n
+	}
+	if b, err := strconv.ParseBool(tok.val); err == nil && !lit.isNum {
+		lit.isBool, lit.boolVal = true, b
+	}
+	if d, err := time.ParseDuration(tok.val); err == nil {
+		lit.isDur, lit.dur = true, d
+	}
+	if lit.isInt {
+		lit.isLevel, lit.level = true, slog.Level(lit.intVal)
+	} else if err := lit.level.UnmarshalText([]byte(tok.val)); err == nil {
+		lit.isLevel = true
+	}
+	lit.parseTime()
+
+	switch f.kind {
+	case fieldLevel:
+		if !lit.isLevel {
+			return lit, fmt.Errorf("invalid level %q", tok.val)
+		}
+	case fieldTime:
+		if !lit.isTime {
+			return lit, fmt.Errorf("invalid time %q", tok.val)
+		}
+	case fieldSeq:
+		if !lit.isInt || lit.intVal < 0 {
+			return lit, fmt.Errorf("invalid sequence number %q", tok.val)
+		}
+	}
+	return lit, nil
+}
+
+// parseTime recognizes RFC 3339 times and "now" with an optional duration offset
+func (lit *literal) parseTime() {
+	if t, err := time.Parse(time.RFC3339Nano, lit.raw); err == nil {
+		lit.isTime, lit.timeVal = true, t
+		return
+	}
+
+	rest, ok := strings.CutPrefix(lit.raw, "now")
+	if !ok {
+		return
+	}
+	if rest == "" {
+		lit.isTime, lit.fromNow = true, true
+		return
+	}
+	if rest[0] != '+' && rest[0] != '-' {
+		return
+	}
+	d, err := time.ParseDuration(rest[1:])
+	if err != nil {
+		return
+	}
+	if rest[0] == '-' {
+		d = -d
+	}
+	lit.isTime, lit.fromNow, lit.offset = true, true, d
+}
+
+// env is the evaluation environment for a single record
+type env struct {
+	record *storage.Record
+	now    time.Time
+}
+
+func newEnv(r *storage.Record) *env {
+	return &env{record: r}
+}
+
+// timeOf returns the literal's time, resolving relative times once per evaluation
+func (e *env) timeOf(lit *literal) time.Time {
+	if !lit.fromNow {
+		return lit.timeVal
+	}
+	if e.now.IsZero() {
+		e.now = time.Now()
+	}
+	return e.now.Add(lit.offset)
+}
+
+// node is a compiled expression node
+type node interface {
+	eval(e *env) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(e *env) bool { return n.left.eval(e) && n.right.eval(e) }
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(e *env) bool { return n.left.eval(e) || n.right.eval(e) }
+
+type notNode struct{ expr node }
+
+func (n *notNode) eval(e *env) bool { return !n.expr.eval(e) }
+
+type existsNode struct{ field field }
+
+func (n *existsNode) eval(e *env) bool {
+	_, ok := e.record.Find(n.field.path...)
+	return ok
+}
+
+type compareNode struct {
+	field field
+	op    operator
+	lit   literal
+}
+
+func (n *compareNode) eval(e *env) bool {
+	r := e.record
+	switch n.field.kind {
+	case fieldLevel:
+		if n.op.isRegex() {
+			return n.matchString(r.Level.String())
+		}
+		return n.op.apply(cmp.Compare(r.Level, n.lit.level))
+	case fieldMsg:
+		return n.compareString(r.Message)
+	case fieldTime:
+		return n.op.apply(r.Time.Compare(e.timeOf(&n.lit)))
+	case fieldSeq:
+		return n.op.apply(cmp.Compare(r.Seq, uint64(n.lit.intVal)))
+	}
+
+	value, ok := r.Find(n.field.path...)
+	if !ok {
+		return false
+	}
+	return n.compareValue(e, value)
+}
+
+// matchString applies a regular expression operator to s
+func (n *compareNode) matchString(s string) bool {
+	matched := n.lit.re.MatchString(s)
+	if n.op == opNotMatch {
+		return !matched
+	}
+	return matched
+}
+
+// compareString compares s with the literal as text
+func (n *compareNode) compareString(s string) bool {
+	if n.op.isRegex() {
+		return n.matchString(s)
+	}
+	return n.op.apply(strings.Compare(s, n.lit.raw))
+}
+
+// compareValue compares an attribute value with the literal, using the kind of
+// the value to decide how the literal is interpreted.
+func (n *compareNode) compareValue(e *env, v slog.Value) bool {
+	if n.op.isRegex() {
+		return n.matchString(v.String())
+	}
+
+	lit := &n.lit
+	switch v.Kind() {
+	case slog.KindInt64:
+		if lit.isInt {
+			return n.op.apply(cmp.Compare(v.Int64(), lit.intVal))
+		}
+		if lit.isNum {
+			return n.op.apply(cmp.Compare(float64(v.Int64()), lit.numVal))
+		}
+	case slog.KindUint64:
+		if lit.isInt {
+			if lit.intVal < 0 {
+				return n.op.apply(1)
+			}
+			return n.op.apply(cmp.Compare(v.Uint64(), uint64(lit.intVal)))
+		}
+		if lit.isNum {
+			return n.op.apply(cmp.Compare(float64(v.Uint64()), lit.numVal))
+		}
+	case slog.KindFloat64:
+		if lit.isNum {
+			return n.op.apply(cmp.Compare(v.Float64(), lit.numVal))
+		}
+	case slog.KindBool:
+		if lit.isBool && (n.op == opEq || n.op == opNe) {
+			return n.op.apply(boolCompare(v.Bool(), lit.boolVal))
+		}
+	case slog.KindDuration:
+		if lit.isDur {
+			return n.op.apply(cmp.Compare(v.Duration(), lit.dur))
+		}
+		if lit.isInt {
+			return n.op.apply(cmp.Compare(int64(v.Duration()), lit.intVal))
+		}
+	case slog.KindTime:
+		if lit.isTime {
+			return n.op.apply(v.Time().Compare(e.timeOf(lit)))
+		}
+	case slog.KindGroup:
+		return false
+	case slog.KindString:
+		// Numeric text compares numerically against a numeric literal
+		if lit.isNum && !lit.quoted {
+			if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+				return n.op.apply(cmp.Compare(f, lit.numVal))
+			}
+		}
+	}
+
+	// Fall back to comparing the text of the value, so that a quoted literal
+	// such as "123" also matches the number 123.
+	if n.op == opEq || n.op == opNe || v.Kind() == slog.KindString || v.Kind() == slog.KindAny {
+		return n.compareString(v.String())
+	}
+	return false
+}
+
+// boolCompare orders false before true
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,282 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenType identifies the kind of a lexical token
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokOp
+	tokString
+	tokWord
+)
+
+// token is a single lexical token with its position in the source
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+// lexer splits an expression into tokens
+type lexer struct {
+	src string
+	pos int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src}
+}
+
+// isDelim reports whether c ends a bare word
+func isDelim(c byte) bool {
+	return strings.IndexByte(" \t\r\n()!&|=<>~\"", c) >= 0
+}
+
+// next returns the next token in the source
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0 {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{typ: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	rest := l.src[l.pos:]
+	emit := func(typ tokenType, n int) (token, error) {
+		l.pos += n
+		return token{typ: typ, val: rest[:n], pos: start}, nil
+	}
+
+	switch {
+	case rest[0] == '(':
+		return emit(tokLParen, 1)
+	case rest[0] == ')':
+		return emit(tokRParen, 1)
+	case strings.HasPrefix(rest, "&&"):
+		return emit(tokAnd, 2)
+	case strings.HasPrefix(rest, "||"):
+		return emit(tokOr, 2)
+	case strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "!="),
+		strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, ">="),
+		strings.HasPrefix(rest, "!~"):
+		return emit(tokOp, 2)
+	case rest[0] == '<', rest[0] == '>', rest[0] == '~':
+		return emit(tokOp, 1)
+	case rest[0] == '!':
+		return emit(tokNot, 1)
+	case rest[0] == '"':
+		return l.lexString()
+	case isDelim(rest[0]):
+		return token{}, fmt.Errorf("query: unexpected %q at position %d", rest[0], start)
+	}
+
+	for l.pos < len(l.src) && !isDelim(l.src[l.pos]) {
+		l.pos++
+	}
+	return token{typ: tokWord, val: l.src[start:l.pos], pos: start}, nil
+}
+
+// lexString reads a double-quoted string with Go escape sequences
+func (l *lexer) lexString() (token, error) {
+	start := l.pos
+	i := l.pos + 1
+	for i < len(l.src) {
+		switch l.src[i] {
+		case '\\':
+			i += 2
+			continue
+		case '"':
+			val, err := strconv.Unquote(l.src[start : i+1])
+			if err != nil {
+				return token{}, fmt.Errorf("query: invalid string at position %d: %w", start, err)
+			}
+			l.pos = i + 1
+			return token{typ: tokString, val: val, pos: start}, nil
+		}
+		i++
+	}
+	return token{}, fmt.Errorf("query: unterminated string at position %d", start)
+}
+
+// parser is a recursive descent parser for filter expressions:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | primary
+//	primary    = "(" expr ")" | field [ op value ]
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// parse parses the whole source into a node tree
+func (p *parser) parse() (node, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.typ == tokEOF {
+		return nil, fmt.Errorf("query: empty expression")
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.typ != tokEOF {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.typ == tokEOF {
+		return fmt.Errorf("query: unexpected end of expression")
+	}
+	return fmt.Errorf("query: unexpected %q at position %d", p.tok.val, p.tok.pos)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.typ == tokOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.typ == tokAnd {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.tok.typ != tokNot {
+		return p.parsePrimary()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &notNode{expr: n}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	switch p.tok.typ {
+	case tokLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.typ != tokRParen {
+			return nil, p.unexpected()
+		}
+		return n, p.advance()
+	case tokWord:
+		return p.parseComparison()
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	f, err := parseField(p.tok)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.typ != tokOp {
+		if f.kind != fieldAttr {
+			return nil, fmt.Errorf("query: field %q needs a comparison", f.name())
+		}
+		return &existsNode{field: f}, nil
+	}
+	op := operator(p.tok.val)
+	opTok := p.tok
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.typ != tokWord && p.tok.typ != tokString {
+		return nil, p.unexpected()
+	}
+
+	lit, err := newLiteral(f, op, p.tok)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w at position %d", err, opTok.pos)
+	}
+	return &compareNode{field: f, op: op, lit: lit}, p.advance()
+}
+
+// parseField converts a word token into a built-in field or an attribute path
+func parseField(tok token) (field, error) {
+	switch tok.val {
+	case "level":
+		return field{kind: fieldLevel}, nil
+	case "msg":
+		return field{kind: fieldMsg}, nil
+	case "time":
+		return field{kind: fieldTime}, nil
+	case "seq":
+		return field{kind: fieldSeq}, nil
+	}
+
+	path := strings.Split(tok.val, ".")
+	for _, part := range path {
+		if part == "" {
+			return field{}, fmt.Errorf("query: invalid field %q at position %d", tok.val, tok.pos)
+		}
+	}
+	return field{kind: fieldAttr, path: path}, nil
+}
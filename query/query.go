@@ -0,0 +1,68 @@
+// Package query compiles one-line filter expressions and evaluates them against
+// realized log records.
+//
+//	expr, err := query.Compile(`level >= WARN && api.user == "123" && msg ~ "timeout"`)
+//	if err != nil {
+//		return err
+//	}
+//	for _, r := range collector.GetLogs() {
+//		if expr.Match(&r) {
+//			fmt.Println(r.Message)
+//		}
+//	}
+//
+// An expression combines comparisons with && (and), || (or), ! (not) and parentheses.
+// A comparison is a field, an operator and a value:
+//
+//	level >= WARN           built-in fields: level, msg, time and seq
+//	api.user == "123"       attributes are addressed by their group path
+//	status >= 500           numbers compare numerically
+//	msg ~ "time(out)?"      ~ and !~ match a regular expression
+//	time > now-10m          times are RFC 3339, or now with an optional offset
+//	api.user                a field alone tests that the attribute exists
+//
+// Operators are ==, !=, <, <=, >, >=, ~ and !~. Values are quoted strings, or bare
+// words such as numbers, level names (including offsets like INFO+2), true and false.
+// A comparison against an attribute that the record does not have is false.
+// The built-in field names shadow top-level attributes with the same key.
+//
+// Expressions are evaluated against realized records, as returned by
+// LogCollector.GetLogs, so that attributes added with WithAttrs and WithGroup are visible.
+package query
+
+import "github.com/robbyt/go-loglater/storage"
+
+// Expr is a compiled filter expression. It is safe for concurrent use.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses a filter expression.
+func Compile(src string) (*Expr, error) {
+	p := &parser{lex: newLexer(src)}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the expression cannot be parsed.
+func MustCompile(src string) *Expr {
+	e, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Match reports whether the realized record satisfies the expression.
+func (e *Expr) Match(r *storage.Record) bool {
+	return e.root.eval(newEnv(r))
+}
+
+// String returns the source text of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
@@ -0,0 +1,183 @@
+package query
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func testRecord() *storage.Record {
+	return &storage.Record{
+		Seq:     7,
+		Time:    time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   slog.LevelWarn,
+		Message: "request timeout",
+		Attrs: []slog.Attr{
+			slog.String("service", "api"),
+			slog.Group("api",
+				slog.String("user", "123"),
+				slog.Int("status", 504),
+				slog.Float64("ratio", 0.25),
+				slog.Bool("retry", true),
+				slog.Duration("elapsed", 1500*time.Millisecond),
+				slog.Time("started", time.Date(2025, 1, 1, 11, 59, 0, 0, time.UTC)),
+				slog.Uint64("bytes", 2048),
+			),
+			slog.String("code", "500"),
+			slog.Int("id", 123),
+		},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		expr     string
+		expected bool
+	}{
+		// Built-in fields
+		{`level >= WARN`, true},
+		{`level > warn`, false},
+		{`level == WARN`, true},
+		{`level < ERROR`, true},
+		{`level >= INFO+2`, true},
+		{`level >= INFO+5`, false},
+		{`level == 4`, true},
+		{`level ~ "^WA"`, true},
+		{`msg == "request timeout"`, true},
+		{`msg ~ "timeout"`, true},
+		{`msg !~ "timeout"`, false},
+		{`msg != "other"`, true},
+		{`time > 2025-01-01T11:00:00Z`, true},
+		{`time < "2025-01-01T11:00:00Z"`, false},
+		{`time < now`, true},
+		{`time > now-10m`, false},
+		{`seq == 7`, true},
+		{`seq > 7`, false},
+
+		// Attributes
+		{`service == "api"`, true},
+		{`service == api`, true},
+		{`api.user == "123"`, true},
+		{`api.user == 123`, true},
+		{`api.status >= 500`, true},
+		{`api.status == 504`, true},
+		{`api.status < 500.5`, false},
+		{`api.status == "504"`, true},
+		{`api.ratio < 0.5`, true},
+		{`api.retry == true`, true},
+		{`api.retry != true`, false},
+		{`api.retry > false`, false},
+		{`api.elapsed > 1s`, true},
+		{`api.elapsed < 1000000000`, false},
+		{`api.started < 2025-01-01T12:00:00Z`, true},
+		{`api.bytes > 1024`, true},
+		{`api.bytes > -1`, true},
+		{`code >= 500`, true},
+		{`code >= "6"`, false},
+		{`id == "123"`, true},
+		{`api ~ "user"`, true},
+		{`api == "x"`, false},
+
+		// Existence and missing attributes
+		{`api.user`, true},
+		{`api.missing`, false},
+		{`!api.missing`, true},
+		{`missing == "x"`, false},
+		{`missing != "x"`, false},
+
+		// Logic
+		{`level >= WARN && api.user == "123" && msg ~ "timeout"`, true},
+		{`level >= ERROR || api.user == "123"`, true},
+		{`level >= ERROR || api.user == "456"`, false},
+		{`!(level >= ERROR) && service == "api"`, true},
+		{`(level >= ERROR || service == "api") && !api.retry == false`, true},
+		{`!!service`, true},
+	}
+
+	record := testRecord()
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := Compile(tc.expr)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if got := expr.Match(record); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []string{
+		``,
+		`   `,
+		`level >=`,
+		`level >= LOUD`,
+		`time > yesterday`,
+		`seq > -1`,
+		`seq > abc`,
+		`level`,
+		`msg`,
+		`msg ~ "("`,
+		`a == "unterminated`,
+		`a == "bad \q escape"`,
+		`(a == b`,
+		`a == b)`,
+		`a == b &&`,
+		`a = b`,
+		`&& a`,
+		`a..b == c`,
+		`a == b c`,
+		`a == ==`,
+	}
+
+	for _, src := range cases {
+		t.Run(src, func(t *testing.T) {
+			if _, err := Compile(src); err == nil {
+				t.Errorf("Expected error compiling %q", src)
+			}
+		})
+	}
+}
+
+func TestMustCompile(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		expr := MustCompile(`level >= WARN`)
+		if expr.String() != `level >= WARN` {
+			t.Errorf("Expected source to be preserved, got %q", expr.String())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected MustCompile to panic")
+			}
+		}()
+		MustCompile(`level >=`)
+	})
+}
+
+func TestMatchRealizedRecord(t *testing.T) {
+	record := storage.Record{
+		Level:   slog.LevelInfo,
+		Message: "test",
+		Attrs:   []slog.Attr{slog.String("user", "123")},
+		Journal: storage.OperationJournal{
+			{Type: storage.OpAttrs, Attrs: []slog.Attr{slog.String("service", "api")}},
+			{Type: storage.OpGroup, Group: "api"},
+		},
+	}
+	realized := record.Realize()
+
+	expr := MustCompile(`service == "api" && api.user == "123"`)
+	if expr.Match(&record) {
+		t.Error("Expected raw record not to match journal attributes")
+	}
+	if !expr.Match(&realized) {
+		t.Error("Expected realized record to match")
+	}
+}
@@ -0,0 +1,28 @@
+package loglater
+
+import "github.com/robbyt/go-loglater/storage"
+
+// Redactor removes sensitive values from a record, including the attributes in its
+// journal. It must replace the record's Attrs and Journal slices rather than modify
+// them, since they may be shared with storage or other records. *redact.Redactor
+// implements it.
+type Redactor interface {
+	Redact(r *storage.Record)
+}
+
+// WithRedactor redacts each record before it is stored, so sensitive values are never
+// held in memory. The record forwarded to the base handler is not redacted; use the
+// handler's own ReplaceAttr for its output.
+func WithRedactor(r Redactor) Option {
+	return func(lc *LogCollector) {
+		lc.redactor = r
+	}
+}
+
+// WithReplayRedactor redacts each record as it is replayed, leaving the stored record
+// unchanged. Filters still see the stored values.
+func WithReplayRedactor(r Redactor) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.redactor = r
+	}
+}
@@ -0,0 +1,212 @@
+// Package redact removes sensitive attribute values, such as tokens and email
+// addresses, from log records.
+//
+//	redactor := redact.New(
+//		redact.Key("auth.token", "password"),
+//		redact.Pattern(regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)),
+//		redact.Type[*http.Cookie](),
+//	)
+//
+//	// Redact at capture, so the values are never stored
+//	collector := loglater.NewLogCollector(handler, loglater.WithRedactor(redactor))
+//
+//	// or only when replaying
+//	collector.PlayLogs(handler, loglater.WithReplayRedactor(redactor))
+//
+// Rules apply to the attributes added with WithAttrs as well as those of the record,
+// and see each attribute's full group path. Values wrapped in Secret are always
+// redacted, whatever the rules.
+package redact
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// Secret wraps a value that must not be logged. It logs as Mask with any handler,
+// and a Redactor replaces it so the wrapped value is not kept in memory:
+//
+//	logger.Info("login", "token", redact.Secret(token))
+type Secret string
+
+// LogValue implements slog.LogValuer
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Mask)
+}
+
+// Rule returns the redacted value of the attribute at path, which holds the keys of
+// its groups followed by its own key, and whether it redacted anything. The value
+// is not resolved, so rules can match LogValuer types.
+type Rule func(path []string, v slog.Value) (slog.Value, bool)
+
+// Key redacts the attributes at the dotted paths, such as "auth.token". A path that
+// names a group redacts the whole group.
+func Key(paths ...string) Rule {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[p] = true
+	}
+	return func(path []string, v slog.Value) (slog.Value, bool) {
+		if set[strings.Join(path, ".")] {
+			return slog.StringValue(Mask), true
+		}
+		return v, false
+	}
+}
+
+// Name redacts attributes with any of the keys, in any group, such as "password"
+func Name(keys ...string) Rule {
+	return func(path []string, v slog.Value) (slog.Value, bool) {
+		if len(path) > 0 && slices.Contains(keys, path[len(path)-1]) {
+			return slog.StringValue(Mask), true
+		}
+		return v, false
+	}
+}
+
+// Pattern replaces each match of re in string values with Mask
+func Pattern(re *regexp.Regexp) Rule {
+	return func(_ []string, v slog.Value) (slog.Value, bool) {
+		resolved := v.Resolve()
+		if resolved.Kind() != slog.KindString || !re.MatchString(resolved.String()) {
+			return v, false
+		}
+		return slog.StringValue(re.ReplaceAllLiteralString(resolved.String(), Mask)), true
+	}
+}
+
+// Type redacts values of type T, such as a credentials struct
+func Type[T any]() Rule {
+	return func(_ []string, v slog.Value) (slog.Value, bool) {
+		if _, ok := v.Any().(T); ok {
+			return slog.StringValue(Mask), true
+		}
+		return v, false
+	}
+}
+
+// Redactor applies redaction rules to records and attributes. It is safe for
+// concurrent use.
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a Redactor that applies the rules in order
+func New(rules ...Rule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// Redact redacts the record's attributes, the attributes in its journal and those
+// captured from the context, which are matched as top-level attributes. Slices
+// that need changes are replaced rather than modified, so a record that shares them
+// with storage or other records can be redacted safely.
+func (r *Redactor) Redact(record *storage.Record) {
+	var groups []string
+	journal := record.Journal
+	copied := false
+
+	for i, op := range journal {
+		switch op.Type {
+		case storage.OpAttrs:
+			attrs, changed := r.attrs(groups, op.Attrs)
+			if !changed {
+				continue
+			}
+			if !copied {
+				journal = slices.Clone(journal)
+				copied = true
+			}
+			journal[i].Attrs = attrs
+		case storage.OpGroup:
+			if op.Group != "" {
+				groups = append(groups, op.Group)
+			}
+		}
+	}
+
+	record.Journal = journal
+	if attrs, changed := r.attrs(groups, record.Attrs); changed {
+		record.Attrs = attrs
+	}
+	if attrs, changed := r.attrs(nil, record.Context); changed {
+		record.Context = attrs
+	}
+}
+
+// Attrs returns the attributes redacted as if they were in the groups. The slice is
+// returned unchanged if nothing was redacted.
+func (r *Redactor) Attrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	result, _ := r.attrs(groups, attrs)
+	return result
+}
+
+// ReplaceAttr redacts a single attribute, for use as slog.HandlerOptions.ReplaceAttr
+// so that a handler's own output is redacted by the same rules
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if v, changed := r.value(attrPath(groups, a.Key), a.Value); changed {
+		a.Value = v
+	}
+	return a
+}
+
+// attrs redacts the attributes, copying the slice on the first change
+func (r *Redactor) attrs(groups []string, attrs []slog.Attr) ([]slog.Attr, bool) {
+	result := attrs
+	copied := false
+
+	for i, a := range attrs {
+		v, changed := r.value(attrPath(groups, a.Key), a.Value)
+		if !changed {
+			continue
+		}
+		if !copied {
+			result = slices.Clone(attrs)
+			copied = true
+		}
+		result[i].Value = v
+	}
+	return result, copied
+}
+
+// value redacts a value by the rules, then descends into groups, resolving
+// LogValuers so the values they produce are redacted too
+func (r *Redactor) value(path []string, v slog.Value) (slog.Value, bool) {
+	if _, ok := v.Any().(Secret); ok {
+		return slog.StringValue(Mask), true
+	}
+
+	changed := false
+	for _, rule := range r.rules {
+		var c bool
+		if v, c = rule(path, v); c {
+			changed = true
+		}
+	}
+
+	resolved := v.Resolve()
+	if resolved.Kind() != slog.KindGroup {
+		return v, changed
+	}
+	members, c := r.attrs(path, resolved.Group())
+	if !c {
+		return v, changed
+	}
+	return slog.GroupValue(members...), true
+}
+
+// attrPath returns the path of the attribute with the key in the groups. The empty
+// key of an inline group adds nothing, as its members are logged in the groups.
+func attrPath(groups []string, key string) []string {
+	path := slices.Clip(groups)
+	if key == "" {
+		return path
+	}
+	return append(path, key)
+}
@@ -0,0 +1,167 @@
+package redact
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+type credentials struct {
+	user, password string
+}
+
+// userValuer is a LogValuer that resolves to a group holding an email address
+type userValuer struct{ email string }
+
+func (u userValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("email", u.email), slog.Int("id", 7))
+}
+
+var emailPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)
+
+func TestRules(t *testing.T) {
+	cases := []struct {
+		name string
+		rule Rule
+		path []string
+		in   slog.Value
+		want string
+	}{
+		{"key matches path", Key("auth.token"), []string{"auth", "token"}, slog.StringValue("abc"), Mask},
+		{"key ignores other group", Key("auth.token"), []string{"token"}, slog.StringValue("abc"), "abc"},
+		{"key redacts group", Key("auth"), []string{"auth"}, slog.GroupValue(slog.String("token", "abc")), Mask},
+		{"name matches any group", Name("password"), []string{"db", "password"}, slog.StringValue("pw"), Mask},
+		{"name ignores groups", Name("db"), []string{"db", "host"}, slog.StringValue("localhost"), "localhost"},
+		{"pattern replaces matches", Pattern(emailPattern), []string{"to"}, slog.StringValue("mail a@b.com and c@d.org"), "mail [REDACTED] and [REDACTED]"},
+		{"pattern ignores numbers", Pattern(regexp.MustCompile(`\d`)), []string{"n"}, slog.IntValue(5), "5"},
+		{"type matches", Type[credentials](), []string{"creds"}, slog.AnyValue(credentials{"u", "p"}), Mask},
+		{"type ignores other types", Type[credentials](), []string{"creds"}, slog.StringValue("u"), "u"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, changed := tc.rule(tc.path, tc.in)
+			if got.String() != tc.want {
+				t.Errorf("got %q, want %q", got.String(), tc.want)
+			}
+			if want := tc.want != tc.in.String(); changed != want {
+				t.Errorf("changed = %v, want %v", changed, want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	redactor := New(Key("api.token"), Name("password"), Pattern(emailPattern))
+
+	journalAttrs := []slog.Attr{slog.String("password", "hunter2"), slog.String("service", "auth")}
+	recordAttrs := []slog.Attr{
+		slog.String("token", "abc"),
+		slog.String("note", "from bob@example.com"),
+		slog.Any("user", userValuer{"carol@example.com"}),
+		slog.Any("session", Secret("s3cr3t")),
+	}
+	record := &storage.Record{
+		Message: "login",
+		Attrs:   recordAttrs,
+		Journal: storage.OperationJournal{
+			{Type: storage.OpAttrs, Attrs: journalAttrs},
+			{Type: storage.OpGroup, Group: "api"},
+		},
+	}
+	original := *record
+
+	redactor.Redact(record)
+	realized := record.Realize()
+
+	for path, want := range map[string]string{
+		"password":       Mask,
+		"service":        "auth",
+		"api.token":      Mask,
+		"api.note":       "from " + Mask,
+		"api.user.email": Mask,
+		"api.user.id":    "7",
+		"api.session":    Mask,
+	} {
+		got, ok := realized.Find(strings.Split(path, ".")...)
+		if !ok || got.String() != want {
+			t.Errorf("%s = %q, want %q", path, got.String(), want)
+		}
+	}
+
+	t.Run("does not modify shared slices", func(t *testing.T) {
+		if journalAttrs[0].Value.String() != "hunter2" || recordAttrs[0].Value.String() != "abc" {
+			t.Error("redaction modified the original attributes")
+		}
+		if original.Journal[0].Attrs[0].Value.String() != "hunter2" {
+			t.Error("redaction modified the original journal")
+		}
+	})
+
+	t.Run("leaves clean records alone", func(t *testing.T) {
+		clean := &storage.Record{Attrs: []slog.Attr{slog.String("status", "ok")}}
+		attrs := clean.Attrs
+		redactor.Redact(clean)
+		if &clean.Attrs[0] != &attrs[0] {
+			t.Error("expected the attributes to be kept when nothing is redacted")
+		}
+	})
+
+	t.Run("context attributes", func(t *testing.T) {
+		r := &storage.Record{Context: []slog.Attr{slog.String("password", "pw"), slog.String("trace_id", "abc")}}
+		redactor.Redact(r)
+		if r.Context[0].Value.String() != Mask || r.Context[1].Value.String() != "abc" {
+			t.Errorf("expected only the password redacted, got %v", r.Context)
+		}
+	})
+
+	t.Run("inline groups", func(t *testing.T) {
+		redactor := New(Key("auth.token"))
+		for name, r := range map[string]*storage.Record{
+			"in a group": {Attrs: []slog.Attr{
+				slog.Group("auth", slog.Group("", slog.String("token", "s3cr3t"))),
+			}},
+			"after WithGroup": {
+				Attrs:   []slog.Attr{slog.Group("", slog.String("token", "s3cr3t"))},
+				Journal: storage.OperationJournal{{Type: storage.OpGroup, Group: "auth"}},
+			},
+		} {
+			redactor.Redact(r)
+			realized := r.Realize()
+			if got, ok := realized.Find("auth", "token"); !ok || got.String() != Mask {
+				t.Errorf("%s: auth.token = %q, want %q", name, got.String(), Mask)
+			}
+		}
+	})
+
+	t.Run("secret without rules", func(t *testing.T) {
+		r := &storage.Record{Attrs: []slog.Attr{slog.Any("key", Secret("value"))}}
+		New().Redact(r)
+		if _, ok := r.Attrs[0].Value.Any().(Secret); ok {
+			t.Error("expected the secret to be replaced")
+		}
+	})
+}
+
+func TestReplaceAttr(t *testing.T) {
+	redactor := New(Key("api.token"))
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactor.ReplaceAttr}))
+
+	logger.WithGroup("api").Info("call", "token", "abc", "user", "bob")
+	if out := buf.String(); strings.Contains(out, "abc") || !strings.Contains(out, "api.token="+Mask) {
+		t.Errorf("expected the token to be redacted, got %s", out)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("login", "token", Secret("abc"))
+	if strings.Contains(buf.String(), "abc") {
+		t.Errorf("expected the secret to be masked, got %s", buf.String())
+	}
+}
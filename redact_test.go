@@ -0,0 +1,87 @@
+package loglater
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/redact"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor := redact.New(redact.Key("auth.token"), redact.Name("password"))
+
+	t.Run("AtCapture", func(t *testing.T) {
+		collector := NewLogCollector(nil, WithRedactor(redactor))
+		logger := slog.New(collector).With("password", "hunter2").WithGroup("auth")
+		logger.Info("login", "token", "abc", "user", "bob")
+
+		logs := collector.GetLogs()
+		if len(logs) != 1 {
+			t.Fatalf("Expected 1 record, got %d", len(logs))
+		}
+		if v, _ := logs[0].Find("password"); v.String() != redact.Mask {
+			t.Errorf("Expected journal attribute to be redacted, got %q", v.String())
+		}
+		if v, _ := logs[0].Find("auth", "token"); v.String() != redact.Mask {
+			t.Errorf("Expected record attribute to be redacted, got %q", v.String())
+		}
+		if v, _ := logs[0].Find("auth", "user"); v.String() != "bob" {
+			t.Errorf("Expected other attributes to be kept, got %q", v.String())
+		}
+	})
+
+	t.Run("ForwardsOriginal", func(t *testing.T) {
+		var buf bytes.Buffer
+		collector := NewLogCollector(slog.NewTextHandler(&buf, nil), WithRedactor(redactor))
+		slog.New(collector).Info("login", "password", "hunter2")
+
+		if !strings.Contains(buf.String(), "hunter2") {
+			t.Errorf("Expected the base handler to receive the original record, got %s", buf.String())
+		}
+	})
+
+	t.Run("AtReplay", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).With("password", "hunter2").Info("login")
+
+		var buf bytes.Buffer
+		if err := collector.PlayLogs(slog.NewTextHandler(&buf, nil), WithReplayRedactor(redactor)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if strings.Contains(buf.String(), "hunter2") {
+			t.Errorf("Expected replayed output to be redacted, got %s", buf.String())
+		}
+
+		if v, _ := collector.GetLogs()[0].Find("password"); v.String() != "hunter2" {
+			t.Errorf("Expected stored record to be unchanged, got %q", v.String())
+		}
+	})
+
+	t.Run("Drain", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("login", "password", "hunter2")
+
+		var buf bytes.Buffer
+		if err := collector.Drain(t.Context(), slog.NewTextHandler(&buf, nil), WithReplayRedactor(redactor)); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+		if strings.Contains(buf.String(), "hunter2") {
+			t.Errorf("Expected drained output to be redacted, got %s", buf.String())
+		}
+	})
+
+	t.Run("PlayFrom", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		slog.New(collector).Info("login", "password", "hunter2")
+
+		var buf bytes.Buffer
+		if _, err := collector.PlayFrom(t.Context(), slog.NewTextHandler(&buf, nil), 0, WithReplayRedactor(redactor)); err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if strings.Contains(buf.String(), "hunter2") {
+			t.Errorf("Expected replayed output to be redacted, got %s", buf.String())
+		}
+	})
+}
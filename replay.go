@@ -0,0 +1,159 @@
+package loglater
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// Attributes added to a replayed record that collapses several occurrences, unless
+// WithExpandDuplicates is set
+const (
+	CountKey     = "count"
+	FirstSeenKey = "first_seen"
+	LastSeenKey  = "last_seen"
+)
+
+// ReplayOption configures how stored logs are replayed by PlayLogsCtx, PlayFrom, Drain
+// and Merged.
+type ReplayOption func(*replayConfig)
+
+// replayConfig holds the settings for a single replay
+type replayConfig struct {
+	filter       func(*storage.Record) bool
+	redactor     Redactor
+	expand       bool
+	contextAttrs bool
+	restore      func(context.Context, []slog.Attr) context.Context
+
+	speed    float64 // pacing speed factor, zero for no pacing
+	maxGap   time.Duration
+	lastTime time.Time // time of the last record replayed, for pacing
+
+	timeMode         timeMode
+	timeOffset       time.Duration
+	timeStart        time.Time
+	firstTime        time.Time // original time of the first record replayed, for relative times
+	originalTimeAttr bool
+
+	workers    int
+	orderKey   []string
+	progress   func(done, total int)
+	progressMu sync.Mutex
+	done       int
+}
+
+// newReplayConfig builds a replay configuration from the options
+func newReplayConfig(opts []ReplayOption) *replayConfig {
+	cfg := &replayConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithFilter replays only the records for which the filter returns true. The filter
+// receives the realized record, as returned by GetLogs. A compiled query expression
+// can be used directly:
+//
+//	expr := query.MustCompile(`level >= WARN && api.user == "123"`)
+//	collector.PlayLogsCtx(ctx, handler, loglater.WithFilter(expr.Match))
+func WithFilter(filter func(*storage.Record) bool) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.filter = filter
+	}
+}
+
+// WithExpandDuplicates replays a record that collapses several occurrences, as
+// stored with storage.WithDedup, once for each occurrence instead of once with
+// CountKey, FirstSeenKey and LastSeenKey attributes. The last occurrence is replayed
+// with the time it was last seen; the times of the ones between were not kept, so
+// they are replayed with the time first seen.
+func WithExpandDuplicates() ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.expand = true
+	}
+}
+
+// match reports whether the stored record passes the configured filter
+func (cfg *replayConfig) match(stored *storage.Record) bool {
+	if cfg.filter == nil {
+		return true
+	}
+	realized := stored.Realize()
+	return cfg.filter(&realized)
+}
+
+// play replays a stored record as configured, stopping at the first handler error
+func (cfg *replayConfig) play(ctx context.Context, handler slog.Handler, stored *storage.Record) error {
+	ctx, records := cfg.prepare(ctx, stored)
+	for i := range records {
+		if err := cfg.schedule(ctx, &records[i]); err != nil {
+			return err
+		}
+		if err := playRecord(ctx, handler, &records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare returns the context and the records to hand to the handler for a stored
+// record: redacted, with its context restored, and with its occurrences annotated or
+// expanded
+func (cfg *replayConfig) prepare(ctx context.Context, stored *storage.Record) (context.Context, []storage.Record) {
+	if cfg.redactor != nil {
+		cfg.redactor.Redact(stored)
+	}
+	if len(stored.Context) > 0 {
+		if cfg.restore != nil {
+			ctx = cfg.restore(ctx, stored.Context)
+		}
+		if cfg.contextAttrs {
+			*stored = stored.MergeContext()
+		}
+	}
+	if stored.Count <= 1 {
+		return ctx, []storage.Record{*stored}
+	}
+
+	if cfg.expand {
+		occurrences := make([]storage.Record, stored.Count)
+		for i := range occurrences {
+			occurrences[i] = *stored
+		}
+		if !stored.LastSeen.IsZero() {
+			occurrences[len(occurrences)-1].Time = stored.LastSeen
+		}
+		return ctx, occurrences
+	}
+
+	annotated := *stored
+	prependAttrs(&annotated,
+		slog.Int(CountKey, stored.Count),
+		slog.Time(FirstSeenKey, stored.Time),
+		slog.Time(LastSeenKey, stored.LastSeen),
+	)
+	return ctx, []storage.Record{annotated}
+}
+
+// schedule waits for the record's turn when pacing, then rewrites its time as
+// configured. Records must be scheduled in replay order.
+func (cfg *replayConfig) schedule(ctx context.Context, r *storage.Record) error {
+	if err := cfg.pace(ctx, r.Time); err != nil {
+		return err
+	}
+	if cfg.timeMode != timeOriginal {
+		cfg.rewriteTime(r)
+	}
+	return nil
+}
+
+// prependAttrs adds top-level attributes to the record through a new journal that
+// starts with them, so they are not nested in the record's groups
+func prependAttrs(r *storage.Record, attrs ...slog.Attr) {
+	r.Journal = append(storage.OperationJournal{{Type: storage.OpAttrs, Attrs: attrs}}, r.Journal...)
+}
@@ -0,0 +1,135 @@
+package loglater
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/query"
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestWithFilter(t *testing.T) {
+	setup := func() *LogCollector {
+		collector := NewLogCollector(nil)
+		logger := slog.New(collector)
+		logger.Info("a")
+		logger.WithGroup("api").With("user", "123").Error("b")
+		logger.Warn("c")
+		logger.WithGroup("api").With("user", "456").Error("d")
+		return collector
+	}
+	expr := query.MustCompile(`level >= ERROR && api.user == "123"`)
+
+	t.Run("PlayLogs", func(t *testing.T) {
+		collector := setup()
+		handler := &failOnMessageHandler{}
+		if err := collector.PlayLogs(handler, WithFilter(expr.Match)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "b" {
+			t.Errorf("Expected only b, got %s", got)
+		}
+	})
+
+	t.Run("FilterSeesRealizedRecord", func(t *testing.T) {
+		collector := setup()
+		var journals int
+		filter := func(r *storage.Record) bool {
+			if _, ok := r.Find("api", "user"); ok {
+				journals++
+			}
+			return true
+		}
+		if err := collector.PlayLogs(&failOnMessageHandler{}, WithFilter(filter)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if journals != 2 {
+			t.Errorf("Expected journal attributes visible in 2 records, got %d", journals)
+		}
+	})
+
+	t.Run("PlayFromAdvancesPastSkipped", func(t *testing.T) {
+		collector := setup()
+		handler := &failOnMessageHandler{}
+		cursor, err := collector.PlayFrom(t.Context(), handler, 0, WithFilter(expr.Match))
+		if err != nil {
+			t.Fatalf("PlayFrom failed: %v", err)
+		}
+		if cursor != 4 {
+			t.Errorf("Expected cursor 4, got %d", cursor)
+		}
+		if got := strings.Join(handler.handled, ","); got != "b" {
+			t.Errorf("Expected only b, got %s", got)
+		}
+	})
+
+	t.Run("DrainKeepsSkipped", func(t *testing.T) {
+		collector := setup()
+		handler := &failOnMessageHandler{}
+		if err := collector.Drain(t.Context(), handler, WithFilter(expr.Match)); err != nil {
+			t.Fatalf("Drain failed: %v", err)
+		}
+
+		var remaining []string
+		for _, r := range collector.GetLogs() {
+			remaining = append(remaining, r.Message)
+		}
+		if got := strings.Join(remaining, ","); got != "a,c,d" {
+			t.Errorf("Expected a,c,d to remain, got %s", got)
+		}
+	})
+}
+
+func TestDuplicates(t *testing.T) {
+	setup := func() *LogCollector {
+		collector := NewLogCollector(nil, WithStorage(storage.NewRecordStorage(storage.WithDedup(0))))
+		logger := slog.New(collector).WithGroup("db")
+		for range 3 {
+			logger.Warn("retrying", "attempt", 1)
+		}
+		logger.Info("connected")
+		return collector
+	}
+
+	t.Run("Annotated", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := setup().PlayLogs(slog.NewJSONHandler(&buf, nil)); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 records, got %d: %s", len(lines), buf.String())
+		}
+		var first map[string]any
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+			t.Fatal(err)
+		}
+		if first[CountKey] != float64(3) || first[FirstSeenKey] == nil || first[LastSeenKey] == nil {
+			t.Errorf("Expected top-level count, first_seen and last_seen, got %s", lines[0])
+		}
+		if strings.Contains(lines[1], CountKey) {
+			t.Errorf("Expected single records not to be annotated, got %s", lines[1])
+		}
+	})
+
+	t.Run("Expanded", func(t *testing.T) {
+		handler := &failOnMessageHandler{}
+		if err := setup().PlayLogs(handler, WithExpandDuplicates()); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		if got := strings.Join(handler.handled, ","); got != "retrying,retrying,retrying,connected" {
+			t.Errorf("Expected each occurrence replayed, got %s", got)
+		}
+	})
+
+	t.Run("ExpandedStopsOnError", func(t *testing.T) {
+		handler := &failOnMessageHandler{fail: "retrying"}
+		if err := setup().PlayLogs(handler, WithExpandDuplicates()); err == nil {
+			t.Error("Expected the handler error")
+		}
+	})
+}
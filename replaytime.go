@@ -0,0 +1,93 @@
+package loglater
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// OriginalTimeKey is the attribute added by WithOriginalTimeAttr
+const OriginalTimeKey = "original_time"
+
+// timeMode selects how the times of replayed records are rewritten
+type timeMode int
+
+const (
+	timeOriginal timeMode = iota
+	timeNow
+	timeShift
+	timeRelative
+	timeZero
+)
+
+// WithReplayTimeNow replays each record with the time it is replayed, for consumers
+// that reject records older than their ingestion window
+func WithReplayTimeNow() ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.timeMode = timeNow
+	}
+}
+
+// WithTimeShift replays each record with its time moved by the offset
+func WithTimeShift(offset time.Duration) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.timeMode = timeShift
+		cfg.timeOffset = offset
+	}
+}
+
+// WithRelativeTime replays the first record with the start time, and each later one
+// with start plus its offset from the first. With a fixed start, such as
+// time.Unix(0, 0), replayed times are the same on every run.
+func WithRelativeTime(start time.Time) ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.timeMode = timeRelative
+		cfg.timeStart = start
+	}
+}
+
+// WithZeroTime replays records with a zero time, which slog's built-in handlers
+// leave out of their output
+func WithZeroTime() ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.timeMode = timeZero
+	}
+}
+
+// WithOriginalTimeAttr adds the time a record was logged as a top-level
+// OriginalTimeKey attribute when its time is rewritten by another option
+func WithOriginalTimeAttr() ReplayOption {
+	return func(cfg *replayConfig) {
+		cfg.originalTimeAttr = true
+	}
+}
+
+// rewriteTime sets the time of a record copy as configured. Records without a time
+// keep it zero, except with WithReplayTimeNow.
+func (cfg *replayConfig) rewriteTime(r *storage.Record) {
+	original := r.Time
+	switch cfg.timeMode {
+	case timeNow:
+		r.Time = time.Now()
+	case timeShift:
+		if !original.IsZero() {
+			r.Time = original.Add(cfg.timeOffset)
+		}
+	case timeRelative:
+		if !original.IsZero() {
+			if cfg.firstTime.IsZero() {
+				cfg.firstTime = original
+			}
+			r.Time = cfg.timeStart.Add(original.Sub(cfg.firstTime))
+		}
+	case timeZero:
+		r.Time = time.Time{}
+	default:
+		return
+	}
+
+	if cfg.originalTimeAttr && !original.IsZero() {
+		prependAttrs(r, slog.Time(OriginalTimeKey, original))
+	}
+}
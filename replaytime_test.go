@@ -0,0 +1,118 @@
+package loglater
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// recordHandler keeps the records it handles, with the attributes added through
+// WithAttrs in front of their own
+type recordHandler struct {
+	attrs   []slog.Attr
+	records *[]slog.Record
+}
+
+func (h recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h recordHandler) Handle(_ context.Context, r slog.Record) error {
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	record.AddAttrs(h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttrs(a)
+		return true
+	})
+	*h.records = append(*h.records, record)
+	return nil
+}
+func (h recordHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	return h
+}
+func (h recordHandler) WithGroup(string) slog.Handler { return h }
+
+func TestReplayTime(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	collector := NewLogCollector(nil)
+	for _, offset := range []time.Duration{0, time.Second, 3 * time.Second} {
+		if err := collector.Handle(t.Context(), slog.NewRecord(base.Add(offset), slog.LevelInfo, "boot", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Unix(0, 0).UTC()
+
+	replay := func(t *testing.T, opts ...ReplayOption) []slog.Record {
+		t.Helper()
+		var records []slog.Record
+		if err := collector.PlayLogs(recordHandler{records: &records}, opts...); err != nil {
+			t.Fatalf("PlayLogs failed: %v", err)
+		}
+		return records
+	}
+
+	cases := []struct {
+		name string
+		opts []ReplayOption
+		want []time.Time
+	}{
+		{"Original", nil, []time.Time{base, base.Add(time.Second), base.Add(3 * time.Second)}},
+		{"Shift", []ReplayOption{WithTimeShift(time.Hour)},
+			[]time.Time{base.Add(time.Hour), base.Add(time.Hour + time.Second), base.Add(time.Hour + 3*time.Second)}},
+		{"Relative", []ReplayOption{WithRelativeTime(start)},
+			[]time.Time{start, start.Add(time.Second), start.Add(3 * time.Second)}},
+		{"Zero", []ReplayOption{WithZeroTime()}, []time.Time{{}, {}, {}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			records := replay(t, tc.opts...)
+			for i, want := range tc.want {
+				if !records[i].Time.Equal(want) {
+					t.Errorf("Record %d: expected %v, got %v", i, want, records[i].Time)
+				}
+			}
+		})
+	}
+
+	t.Run("RelativeRestartsEachReplay", func(t *testing.T) {
+		opt := WithRelativeTime(start)
+		replay(t, opt)
+		if records := replay(t, opt); !records[0].Time.Equal(start) {
+			t.Errorf("Expected the first record at the start again, got %v", records[0].Time)
+		}
+	})
+
+	t.Run("Now", func(t *testing.T) {
+		before := time.Now()
+		for _, r := range replay(t, WithReplayTimeNow()) {
+			if r.Time.Before(before) {
+				t.Errorf("Expected the replay time, got %v", r.Time)
+			}
+		}
+	})
+
+	t.Run("OriginalTimeAttr", func(t *testing.T) {
+		records := replay(t, WithZeroTime(), WithOriginalTimeAttr())
+		var original time.Time
+		records[1].Attrs(func(a slog.Attr) bool {
+			if a.Key == OriginalTimeKey {
+				original = a.Value.Time()
+			}
+			return true
+		})
+		if !original.Equal(base.Add(time.Second)) {
+			t.Errorf("Expected the original time attribute, got %v", original)
+		}
+
+		if replay(t, WithOriginalTimeAttr())[0].NumAttrs() != 0 {
+			t.Error("Expected no attribute when the time is not rewritten")
+		}
+	})
+
+	t.Run("StoredRecordsUnchanged", func(t *testing.T) {
+		replay(t, WithZeroTime(), WithOriginalTimeAttr())
+		logs := collector.GetLogs()
+		if !logs[0].Time.Equal(base) || len(logs[0].Attrs) != 0 {
+			t.Errorf("Expected the stored record unchanged, got %+v", logs[0])
+		}
+	})
+}
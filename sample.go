@@ -0,0 +1,28 @@
+package loglater
+
+import "github.com/robbyt/go-loglater/storage"
+
+// Sampler decides which records are stored, so that a burst of repeated records does
+// not evict everything else from storage. *sample.Sampler implements it.
+type Sampler interface {
+	// Sample reports whether to store the record. It may add attributes to the
+	// record, such as the number of records suppressed before it.
+	Sample(r *storage.Record) bool
+}
+
+// SampleSummarizer is implemented by samplers that report suppressed records as a
+// separate summary record rather than on the next stored record.
+type SampleSummarizer interface {
+	// Summary returns a record describing the records suppressed since the last
+	// call, or nil if there were none. The collector stores it just before the
+	// next record that is kept.
+	Summary() *storage.Record
+}
+
+// WithSampler stores only the records the sampler keeps. Every record is still
+// forwarded to the base handler.
+func WithSampler(s Sampler) Option {
+	return func(lc *LogCollector) {
+		lc.sampler = s
+	}
+}
@@ -0,0 +1,155 @@
+package sample
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// maxKeys bounds the per-key state of a policy. When a policy has seen more keys
+// than this, its state is cleared and every key starts over.
+const maxKeys = 10_000
+
+// Policy reports whether to keep a record, and the key it was limited by when it
+// is not kept, such as its message. Policies are called concurrently.
+type Policy func(r *storage.Record) (keep bool, key string)
+
+// KeyFunc returns the key that a policy limits a record by
+type KeyFunc func(r *storage.Record) string
+
+// ByMessage keys records by their message, which for slog is usually a fixed template
+func ByMessage(r *storage.Record) string {
+	return r.Message
+}
+
+// ByAttr keys records by the value of the attribute at the dotted path, such as
+// "tenant.id", in the realized record: attributes added with WithAttrs are included,
+// and attributes logged after WithGroup are found under the group. Records without
+// the attribute share the empty key.
+func ByAttr(path string) KeyFunc {
+	keys := strings.Split(path, ".")
+	return func(r *storage.Record) string {
+		v, ok := r.FindRealized(keys...)
+		if !ok {
+			return ""
+		}
+		return v.String()
+	}
+}
+
+// LevelRates keeps records at each level with the given probability, from 0 (none)
+// to 1 (all). Levels without a rate are always kept. Suppressed records are keyed
+// by their level.
+func LevelRates(rates map[slog.Level]float64) Policy {
+	return func(r *storage.Record) (bool, string) {
+		rate, ok := rates[r.Level]
+		if !ok || rate >= 1 || (rate > 0 && rand.Float64() < rate) {
+			return true, ""
+		}
+		return false, r.Level.String()
+	}
+}
+
+// RateLimit keeps up to perSecond records per second for each key, allowing bursts
+// of up to burst records, using a token bucket per key. Time is taken from the
+// records, so the limit follows the time the records were logged.
+func RateLimit(perSecond float64, burst int, key KeyFunc) Policy {
+	type bucket struct {
+		tokens float64
+		last   time.Time
+	}
+	var mu sync.Mutex
+	buckets := make(map[string]*bucket)
+
+	return func(r *storage.Record) (bool, string) {
+		k := key(r)
+		now := recordTime(r)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		b, ok := buckets[k]
+		if !ok {
+			if len(buckets) >= maxKeys {
+				clear(buckets)
+			}
+			b = &bucket{tokens: float64(burst), last: now}
+			buckets[k] = b
+		}
+		if elapsed := now.Sub(b.last); elapsed > 0 {
+			b.tokens = min(float64(burst), b.tokens+elapsed.Seconds()*perSecond)
+			b.last = now
+		}
+		if b.tokens < 1 {
+			return false, k
+		}
+		b.tokens--
+		return true, k
+	}
+}
+
+// FirstThenEvery keeps the first records for each key, then every every-th record
+// after that. The counts start over each period, measured from the first record of
+// the period; a period of zero never starts over. A value of every below 1 keeps
+// none after the first.
+func FirstThenEvery(first, every int, period time.Duration, key KeyFunc) Policy {
+	type counter struct {
+		count int
+		start time.Time
+	}
+	var mu sync.Mutex
+	counters := make(map[string]*counter)
+
+	return func(r *storage.Record) (bool, string) {
+		k := key(r)
+		now := recordTime(r)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		c, ok := counters[k]
+		if !ok {
+			if len(counters) >= maxKeys {
+				clear(counters)
+			}
+			c = &counter{start: now}
+			counters[k] = c
+		}
+		if period > 0 && now.Sub(c.start) >= period {
+			c.count = 0
+			c.start = now
+		}
+
+		c.count++
+		if c.count <= first {
+			return true, k
+		}
+		return every > 0 && (c.count-first)%every == 0, k
+	}
+}
+
+// All keeps a record only if every policy keeps it. The policies are applied in
+// order, stopping at the first that rejects the record, so later policies count
+// only the records that earlier ones kept.
+func All(policies ...Policy) Policy {
+	return func(r *storage.Record) (bool, string) {
+		for _, p := range policies {
+			if keep, key := p(r); !keep {
+				return false, key
+			}
+		}
+		return true, ""
+	}
+}
+
+// recordTime returns the time of the record, or the current time if it has none
+func recordTime(r *storage.Record) time.Time {
+	if r.Time.IsZero() {
+		return time.Now()
+	}
+	return r.Time
+}
@@ -0,0 +1,166 @@
+package sample
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns a record with the message logged at offset from start
+func at(offset time.Duration, msg string, attrs ...slog.Attr) *storage.Record {
+	return &storage.Record{Time: start.Add(offset), Level: slog.LevelInfo, Message: msg, Attrs: attrs}
+}
+
+// kept returns the results of applying the policy to each record in turn
+func kept(p Policy, records ...*storage.Record) []bool {
+	result := make([]bool, len(records))
+	for i, r := range records {
+		result[i], _ = p(r)
+	}
+	return result
+}
+
+func TestKeyFuncs(t *testing.T) {
+	r := &storage.Record{
+		Message: "request",
+		Attrs:   []slog.Attr{slog.String("path", "/a")},
+		Journal: storage.OperationJournal{
+			{Type: storage.OpAttrs, Attrs: []slog.Attr{slog.Group("tenant", slog.Int("id", 7))}},
+		},
+	}
+
+	cases := []struct {
+		name string
+		key  KeyFunc
+		want string
+	}{
+		{"message", ByMessage, "request"},
+		{"record attr", ByAttr("path"), "/a"},
+		{"journal attr", ByAttr("tenant.id"), "7"},
+		{"missing attr", ByAttr("user"), ""},
+	}
+
+	grouped := &storage.Record{
+		Attrs:   []slog.Attr{slog.String("tenant", "a")},
+		Journal: storage.OperationJournal{{Type: storage.OpGroup, Group: "req"}},
+	}
+	for _, tc := range []struct {
+		path string
+		want string
+	}{{"tenant", ""}, {"req.tenant", "a"}} {
+		t.Run("grouped "+tc.path, func(t *testing.T) {
+			if got := ByAttr(tc.path)(grouped); got != tc.want {
+				t.Errorf("key = %q, want %q", got, tc.want)
+			}
+		})
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.key(r); got != tc.want {
+				t.Errorf("key = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLevelRates(t *testing.T) {
+	p := LevelRates(map[slog.Level]float64{
+		slog.LevelDebug: 0,
+		slog.LevelInfo:  0.5,
+		slog.LevelWarn:  1,
+	})
+
+	t.Run("zero drops all", func(t *testing.T) {
+		keep, key := p(&storage.Record{Level: slog.LevelDebug})
+		if keep || key != "DEBUG" {
+			t.Errorf("got keep=%v key=%q, want false DEBUG", keep, key)
+		}
+	})
+
+	t.Run("one and unlisted keep all", func(t *testing.T) {
+		for _, level := range []slog.Level{slog.LevelWarn, slog.LevelError} {
+			if keep, _ := p(&storage.Record{Level: level}); !keep {
+				t.Errorf("expected %s to be kept", level)
+			}
+		}
+	})
+
+	t.Run("fraction keeps some", func(t *testing.T) {
+		n := 0
+		for range 10_000 {
+			if keep, _ := p(&storage.Record{Level: slog.LevelInfo}); keep {
+				n++
+			}
+		}
+		if n < 4000 || n > 6000 {
+			t.Errorf("kept %d of 10000 at rate 0.5", n)
+		}
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	p := RateLimit(2, 3, ByMessage)
+
+	got := kept(p,
+		at(0, "a"), at(0, "a"), at(0, "a"), at(0, "a"), // burst of 3
+		at(0, "b"),                    // other keys have their own bucket
+		at(250*time.Millisecond, "a"), // half a token
+		at(500*time.Millisecond, "a"), // one token
+		at(10*time.Second, "a"),       // refilled to the burst
+		at(10*time.Second, "a"), at(10*time.Second, "a"), at(10*time.Second, "a"),
+	)
+	want := []bool{true, true, true, false, true, false, true, true, true, true, false}
+	if !slices.Equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+
+	if _, key := p(at(10*time.Second, "a")); key != "a" {
+		t.Errorf("key = %q, want a", key)
+	}
+}
+
+func TestFirstThenEvery(t *testing.T) {
+	t.Run("counts per key", func(t *testing.T) {
+		p := FirstThenEvery(2, 3, 0, ByMessage)
+		var records []*storage.Record
+		for range 8 {
+			records = append(records, at(0, "a"))
+		}
+		records = append(records, at(0, "b"))
+
+		want := []bool{true, true, false, false, true, false, false, true, true}
+		if got := kept(p, records...); !slices.Equal(got, want) {
+			t.Errorf("kept %v, want %v", got, want)
+		}
+	})
+
+	t.Run("starts over each period", func(t *testing.T) {
+		p := FirstThenEvery(1, 0, time.Second, ByMessage)
+		got := kept(p, at(0, "a"), at(500*time.Millisecond, "a"), at(time.Second, "a"), at(1500*time.Millisecond, "a"))
+		want := []bool{true, false, true, false}
+		if !slices.Equal(got, want) {
+			t.Errorf("kept %v, want %v", got, want)
+		}
+	})
+}
+
+func TestAll(t *testing.T) {
+	limited := FirstThenEvery(1, 0, 0, ByMessage)
+	p := All(LevelRates(map[slog.Level]float64{slog.LevelDebug: 0}), limited)
+
+	if keep, key := p(&storage.Record{Level: slog.LevelDebug, Message: "a"}); keep || key != "DEBUG" {
+		t.Errorf("got keep=%v key=%q, want the first rejecting policy's key", keep, key)
+	}
+	// The debug record stopped at the first policy, so "a" has not been counted yet
+	if keep, _ := p(&storage.Record{Level: slog.LevelInfo, Message: "a"}); !keep {
+		t.Error("expected the first info record to be kept")
+	}
+	if keep, key := p(&storage.Record{Level: slog.LevelInfo, Message: "a"}); keep || key != "a" {
+		t.Errorf("got keep=%v key=%q, want false a", keep, key)
+	}
+}
@@ -0,0 +1,127 @@
+// Package sample limits which records a collector stores during bursts of repeated
+// logs, so the buffer keeps a variety of records instead of one message repeated.
+//
+//	sampler := sample.New(sample.All(
+//		sample.LevelRates(map[slog.Level]float64{slog.LevelDebug: 0.1}),
+//		sample.FirstThenEvery(100, 50, time.Second, sample.ByMessage),
+//		sample.RateLimit(10, 20, sample.ByAttr("tenant")),
+//	))
+//	collector := loglater.NewLogCollector(handler, loglater.WithSampler(sampler))
+//
+// Sampling applies only to storage: every record is still forwarded to the base
+// handler. The number of records suppressed is added to the next stored record as
+// the attribute "suppressed", or with WithSummary stored as a separate record.
+package sample
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// SuppressedKey is the attribute holding the number of records suppressed
+const SuppressedKey = "suppressed"
+
+// SummaryMessage is the message of the summary records stored with WithSummary
+const SummaryMessage = "records suppressed"
+
+// Option configures a Sampler
+type Option func(*Sampler)
+
+// WithSummary reports suppressed records as a separate warning record, stored just
+// before the next record that is kept, instead of adding the count to that record.
+// The summary has the total in SuppressedKey and the count for each key of the
+// policies that suppressed records in a "by_key" group.
+func WithSummary() Option {
+	return func(s *Sampler) {
+		s.summary = true
+	}
+}
+
+// Sampler applies a policy to each record and counts the records it suppresses. It
+// implements loglater.Sampler and loglater.SampleSummarizer, and is safe for
+// concurrent use.
+type Sampler struct {
+	policy  Policy
+	summary bool
+
+	mu         sync.Mutex
+	total      int
+	suppressed map[string]int
+	keys       []string // suppressed keys in the order first seen
+}
+
+// New creates a Sampler that stores the records the policy keeps
+func New(policy Policy, opts ...Option) *Sampler {
+	s := &Sampler{
+		policy:     policy,
+		suppressed: make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Sample reports whether the record should be stored. Unless WithSummary is set, a
+// kept record that follows suppressed ones gets their count as a top-level
+// SuppressedKey attribute.
+func (s *Sampler) Sample(r *storage.Record) bool {
+	keep, key := s.policy(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !keep {
+		if s.suppressed[key] == 0 {
+			s.keys = append(s.keys, key)
+		}
+		s.suppressed[key]++
+		s.total++
+		return false
+	}
+
+	if !s.summary && s.total > 0 {
+		// Prepend to the journal so the attribute is not nested in the record's groups
+		op := storage.Operation{Type: storage.OpAttrs, Attrs: []slog.Attr{slog.Int(SuppressedKey, s.total)}}
+		r.Journal = append(storage.OperationJournal{op}, r.Journal...)
+		s.reset()
+	}
+	return true
+}
+
+// Summary returns a record describing the records suppressed since the last
+// summary, or nil if there were none or WithSummary is not set
+func (s *Sampler) Summary() *storage.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.summary || s.total == 0 {
+		return nil
+	}
+
+	byKey := make([]slog.Attr, 0, len(s.keys))
+	for _, key := range s.keys {
+		byKey = append(byKey, slog.Int(key, s.suppressed[key]))
+	}
+	summary := &storage.Record{
+		Time:    time.Now(),
+		Level:   slog.LevelWarn,
+		Message: SummaryMessage,
+		Attrs: []slog.Attr{
+			slog.Int(SuppressedKey, s.total),
+			{Key: "by_key", Value: slog.GroupValue(byKey...)},
+		},
+	}
+	s.reset()
+	return summary
+}
+
+// reset clears the suppressed counts; the caller must hold the lock
+func (s *Sampler) reset() {
+	s.total = 0
+	s.keys = s.keys[:0]
+	clear(s.suppressed)
+}
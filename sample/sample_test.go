@@ -0,0 +1,83 @@
+package sample
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestSampler(t *testing.T) {
+	t.Run("attaches count to next kept record", func(t *testing.T) {
+		s := New(FirstThenEvery(1, 0, 0, ByMessage))
+		group := storage.OperationJournal{{Type: storage.OpGroup, Group: "api"}}
+
+		if !s.Sample(at(0, "a")) {
+			t.Fatal("expected the first record to be kept")
+		}
+		for range 3 {
+			if s.Sample(at(0, "a")) {
+				t.Fatal("expected repeats to be suppressed")
+			}
+		}
+
+		next := at(0, "b")
+		next.Journal = group
+		if !s.Sample(next) {
+			t.Fatal("expected another message to be kept")
+		}
+		realized := next.Realize()
+		if v, ok := realized.Find(SuppressedKey); !ok || v.Int64() != 3 {
+			t.Errorf("expected top-level %s=3, got %v", SuppressedKey, realized.Attrs)
+		}
+		if len(group) != 1 {
+			t.Error("expected the original journal to be unchanged")
+		}
+
+		after := at(0, "c")
+		s.Sample(after)
+		if realized := after.Realize(); len(realized.Attrs) != 0 {
+			t.Error("expected the count to be reset after it is reported")
+		}
+		if s.Summary() != nil {
+			t.Error("expected no summary without WithSummary")
+		}
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		s := New(FirstThenEvery(1, 0, 0, ByMessage), WithSummary())
+		if s.Summary() != nil {
+			t.Error("expected no summary before anything is suppressed")
+		}
+
+		for _, msg := range []string{"a", "a", "b", "b", "a", "b"} {
+			s.Sample(at(0, msg))
+		}
+		next := at(0, "c")
+		s.Sample(next)
+		if len(next.Attrs) != 0 || len(next.Journal) != 0 {
+			t.Errorf("expected the kept record to be unchanged, got %v", next)
+		}
+
+		summary := s.Summary()
+		if summary == nil {
+			t.Fatal("expected a summary")
+		}
+		if summary.Message != SummaryMessage || summary.Level != slog.LevelWarn {
+			t.Errorf("unexpected summary %q at %s", summary.Message, summary.Level)
+		}
+		for path, want := range map[string]int64{SuppressedKey: 4, "by_key.a": 2, "by_key.b": 2} {
+			keys := []string{path}
+			if path != SuppressedKey {
+				keys = []string{"by_key", path[len("by_key."):]}
+			}
+			if v, ok := summary.Find(keys...); !ok || v.Int64() != want {
+				t.Errorf("%s = %v, want %d", path, v, want)
+			}
+		}
+
+		if s.Summary() != nil {
+			t.Error("expected the counts to be reset after a summary")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package loglater
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/go-loglater/sample"
+)
+
+func TestSampler(t *testing.T) {
+	t.Run("StoresKeptRecords", func(t *testing.T) {
+		var buf bytes.Buffer
+		sampler := sample.New(sample.FirstThenEvery(2, 0, 0, sample.ByMessage))
+		collector := NewLogCollector(slog.NewTextHandler(&buf, nil), WithSampler(sampler))
+		logger := slog.New(collector)
+
+		for range 5 {
+			logger.Info("hot loop")
+		}
+		logger.Info("done")
+
+		logs := collector.GetLogs()
+		if len(logs) != 3 {
+			t.Fatalf("Expected 3 stored records, got %d", len(logs))
+		}
+		if v, ok := logs[2].Find(sample.SuppressedKey); !ok || v.Int64() != 3 {
+			t.Errorf("Expected the next record to carry the suppressed count, got %v", logs[2].Attrs)
+		}
+		if n := strings.Count(buf.String(), "hot loop"); n != 5 {
+			t.Errorf("Expected all records to reach the base handler, got %d", n)
+		}
+	})
+
+	t.Run("StoresSummary", func(t *testing.T) {
+		sampler := sample.New(sample.RateLimit(1, 1, sample.ByMessage), sample.WithSummary())
+		collector := NewLogCollector(nil, WithSampler(sampler))
+		logger := slog.New(collector)
+
+		for range 4 {
+			logger.Info("hot loop")
+		}
+		logger.Info("done")
+
+		logs := collector.GetLogs()
+		if len(logs) != 3 {
+			t.Fatalf("Expected 3 stored records, got %d", len(logs))
+		}
+		if logs[1].Message != sample.SummaryMessage || logs[2].Message != "done" {
+			t.Fatalf("Expected the summary before the next record, got %q, %q", logs[1].Message, logs[2].Message)
+		}
+		if v, _ := logs[1].Find(sample.SuppressedKey); v.Int64() != 3 {
+			t.Errorf("Expected 3 suppressed, got %v", v)
+		}
+	})
+}
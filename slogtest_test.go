@@ -0,0 +1,111 @@
+package loglater
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+// TestSlogtest checks the collector against the slog.Handler conformance tests,
+// both when forwarding to an underlying handler and when logs are replayed or
+// realized after collection.
+func TestSlogtest(t *testing.T) {
+	t.Run("Passthrough", func(t *testing.T) {
+		var buf bytes.Buffer
+		slogtest.Run(t, func(t *testing.T) slog.Handler {
+			buf.Reset()
+			return NewLogCollector(slog.NewJSONHandler(&buf, nil))
+		}, func(t *testing.T) map[string]any {
+			return parseJSONLines(t, buf.Bytes())[0]
+		})
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		var collector *LogCollector
+		slogtest.Run(t, func(t *testing.T) slog.Handler {
+			collector = NewLogCollector(nil)
+			return collector
+		}, func(t *testing.T) map[string]any {
+			var buf bytes.Buffer
+			if err := collector.PlayLogs(slog.NewJSONHandler(&buf, nil)); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+			return parseJSONLines(t, buf.Bytes())[0]
+		})
+	})
+
+	t.Run("ReplayAll", func(t *testing.T) {
+		collector := NewLogCollector(nil)
+		err := slogtest.TestHandler(collector, func() []map[string]any {
+			var buf bytes.Buffer
+			if err := collector.PlayLogs(slog.NewJSONHandler(&buf, nil)); err != nil {
+				t.Fatalf("PlayLogs failed: %v", err)
+			}
+			return parseJSONLines(t, buf.Bytes())
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Realize", func(t *testing.T) {
+		var collector *LogCollector
+		slogtest.Run(t, func(t *testing.T) slog.Handler {
+			collector = NewLogCollector(nil)
+			return collector
+		}, func(t *testing.T) map[string]any {
+			records := collector.GetLogs()
+			if len(records) != 1 {
+				t.Fatalf("Expected 1 record, got %d", len(records))
+			}
+			return recordMap(&records[0])
+		})
+	})
+}
+
+// parseJSONLines decodes JSON handler output into one map per line
+func parseJSONLines(t *testing.T, data []byte) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for line := range bytes.Lines(data) {
+		var m map[string]any
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", line, err)
+		}
+		result = append(result, m)
+	}
+	if len(result) == 0 {
+		t.Fatal("No output")
+	}
+	return result
+}
+
+// recordMap converts a realized record to the form expected by slogtest, without
+// any of the normalization a handler would apply, so that Realize has to do it
+func recordMap(r *storage.Record) map[string]any {
+	m := attrsMap(r.Attrs)
+	if !r.Time.IsZero() {
+		m[slog.TimeKey] = r.Time
+	}
+	m[slog.LevelKey] = r.Level
+	m[slog.MessageKey] = r.Message
+	return m
+}
+
+// attrsMap converts attributes to a map, with groups as nested maps
+func attrsMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			m[attr.Key] = attrsMap(value.Group())
+		} else {
+			m[attr.Key] = value.Any()
+		}
+	}
+	return m
+}
@@ -0,0 +1,15 @@
+package loglater
+
+import "github.com/robbyt/go-loglater/storage"
+
+// Stats aggregates the collected logs without replaying them, for example to count
+// the errors of the last ten minutes by component:
+//
+//	stats := collector.Stats(
+//		storage.WithStatsSince(time.Now().Add(-10*time.Minute)),
+//		storage.WithStatsFilter(func(r *storage.Record) bool { return r.Level >= slog.LevelError }),
+//		storage.WithStatsAttr("component"),
+//	)
+func (c *LogCollector) Stats(opts ...storage.StatsOption) storage.Stats {
+	return storage.ComputeStats(c.GetLogs(), opts...)
+}
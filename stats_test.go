@@ -0,0 +1,28 @@
+package loglater
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/robbyt/go-loglater/storage"
+)
+
+func TestStats(t *testing.T) {
+	collector := NewLogCollector(nil)
+	logger := slog.New(collector)
+	logger.With("component", "db").Error("query failed")
+	logger.With("component", "db").Error("query failed")
+	logger.With("component", "api").Info("request")
+
+	stats := collector.Stats(storage.WithStatsAttr("component"))
+
+	if stats.Total != 3 {
+		t.Errorf("Expected 3 records, got %d", stats.Total)
+	}
+	if stats.ByLevel[slog.LevelError] != 2 {
+		t.Errorf("Expected 2 errors, got %d", stats.ByLevel[slog.LevelError])
+	}
+	if stats.ByAttr["db"] != 2 || stats.ByAttr["api"] != 1 {
+		t.Errorf("Expected journal attributes to be counted, got %v", stats.ByAttr)
+	}
+}
@@ -0,0 +1,203 @@
+package loglater
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogPrefixKey holds the prefix set with log.SetPrefix on records captured from the
+// log package
+const LogPrefixKey = "log_prefix"
+
+// CaptureOption configures CaptureDefault
+type CaptureOption func(*captureConfig)
+
+// captureConfig holds the settings for capturing the log package's output
+type captureConfig struct {
+	level       slog.Level
+	parseLevels bool
+}
+
+// WithCaptureLevel sets the level of records captured from the log package. The
+// default is slog.LevelInfo.
+func WithCaptureLevel(level slog.Level) CaptureOption {
+	return func(cfg *captureConfig) {
+		cfg.level = level
+	}
+}
+
+// WithCaptureLevelParsing takes the level of records captured from the log package
+// from a level at the start of the message, such as "ERROR: failed" or "[WARN]
+// retrying", and removes it from the message. Messages without one are captured at
+// the configured level.
+func WithCaptureLevelParsing() CaptureOption {
+	return func(cfg *captureConfig) {
+		cfg.parseLevels = true
+	}
+}
+
+// CaptureDefault makes the collector the default slog logger, and captures the
+// output of the log package's default logger as records, so that output from
+// dependencies is buffered together with the application's own. It returns a
+// function that restores the previous default logger and the log package's output,
+// flags and prefix.
+//
+// The header the log package writes for its flags is parsed rather than kept in the
+// message: the file and line become the slog.SourceKey attribute and the prefix the
+// LogPrefixKey attribute. Records are timed when they are written. The collector's
+// handler must not write to the log package, as slog's default handler does.
+//
+//	restore := loglater.CaptureDefault(collector, loglater.WithCaptureLevelParsing())
+//	defer restore()
+func CaptureDefault(collector *LogCollector, opts ...CaptureOption) (restore func()) {
+	cfg := &captureConfig{level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	previous := slog.Default()
+	output, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+
+	// SetDefault redirects the log package to the handler, without parsing
+	// its header, and clears its flags, so take over from it
+	slog.SetDefault(slog.New(collector))
+	log.SetOutput(&logWriter{collector: collector, cfg: cfg})
+	log.SetFlags(flags)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			slog.SetDefault(previous)
+			log.SetOutput(output)
+			log.SetFlags(flags)
+			log.SetPrefix(prefix)
+		})
+	}
+}
+
+// logWriter turns the lines written by the log package's default logger into records
+type logWriter struct {
+	collector *LogCollector
+	cfg       *captureConfig
+}
+
+// Write implements io.Writer. The log package writes each line with a single call.
+func (w *logWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	ctx := context.Background()
+
+	msg, attrs := parseStdLine(strings.TrimSuffix(string(p), "\n"), log.Flags(), log.Prefix())
+	level := w.cfg.level
+	if w.cfg.parseLevels {
+		level, msg = parseStdLevel(msg, level)
+	}
+	if !w.collector.Enabled(ctx, level) {
+		return len(p), nil
+	}
+
+	r := slog.NewRecord(now, level, msg, 0)
+	r.AddAttrs(attrs...)
+	if err := w.collector.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// parseStdLine splits the header the log package writes for the flags and prefix off
+// a line, returning the message and the attributes for the header. A line that does
+// not start with the expected header is returned whole.
+func parseStdLine(line string, flags int, prefix string) (string, []slog.Attr) {
+	var attrs []slog.Attr
+	if p := strings.TrimSpace(prefix); p != "" {
+		attrs = append(attrs, slog.String(LogPrefixKey, p))
+	}
+
+	rest := line
+	if flags&log.Lmsgprefix == 0 {
+		var ok bool
+		if rest, ok = strings.CutPrefix(rest, prefix); !ok {
+			return line, nil
+		}
+	}
+
+	if layout := stdTimeLayout(flags); layout != "" {
+		if len(rest) <= len(layout) || rest[len(layout)] != ' ' {
+			return line, nil
+		}
+		if _, err := time.Parse(layout, rest[:len(layout)]); err != nil {
+			return line, nil
+		}
+		rest = rest[len(layout)+1:]
+	}
+
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		source, after, ok := strings.Cut(rest, ": ")
+		if !ok {
+			return line, nil
+		}
+		i := strings.LastIndexByte(source, ':')
+		if i < 0 {
+			return line, nil
+		}
+		if _, err := strconv.Atoi(source[i+1:]); err != nil {
+			return line, nil
+		}
+		attrs = append(attrs, slog.String(slog.SourceKey, source))
+		rest = after
+	}
+
+	if flags&log.Lmsgprefix != 0 {
+		var ok bool
+		if rest, ok = strings.CutPrefix(rest, prefix); !ok {
+			return line, nil
+		}
+	}
+	return rest, attrs
+}
+
+// stdTimeLayout returns the layout of the date and time the log package writes for
+// the flags, or "" if it writes none
+func stdTimeLayout(flags int) string {
+	var parts []string
+	if flags&log.Ldate != 0 {
+		parts = append(parts, "2006/01/02")
+	}
+	switch {
+	case flags&log.Lmicroseconds != 0:
+		parts = append(parts, "15:04:05.000000")
+	case flags&log.Ltime != 0:
+		parts = append(parts, "15:04:05")
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseStdLevel takes a level written as "LEVEL:" or "[LEVEL]" at the start of the
+// message, in any case, returning the level and the rest of the message
+func parseStdLevel(msg string, fallback slog.Level) (slog.Level, string) {
+	var word, rest string
+	if after, ok := strings.CutPrefix(msg, "["); ok {
+		var found bool
+		if word, rest, found = strings.Cut(after, "]"); !found {
+			return fallback, msg
+		}
+	} else {
+		var found bool
+		if word, rest, found = strings.Cut(msg, ":"); !found {
+			return fallback, msg
+		}
+	}
+
+	if strings.EqualFold(word, "warning") {
+		word = "warn"
+	}
+	var level slog.Level
+	if strings.ContainsAny(word, " \t") || level.UnmarshalText([]byte(word)) != nil {
+		return fallback, msg
+	}
+	return level, strings.TrimLeft(rest, " ")
+}
@@ -0,0 +1,126 @@
+package loglater
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCaptureDefault(t *testing.T) {
+	previous := slog.Default()
+	output, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+
+	collector := NewLogCollector(nil)
+	restore := CaptureDefault(collector, WithCaptureLevelParsing())
+
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[db] ")
+	log.Printf("connected to %s", "primary")
+	log.Print("ERROR: connection lost")
+	slog.Warn("from slog", "attempt", 2)
+	restore()
+
+	logs := collector.GetLogs()
+	if len(logs) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(logs))
+	}
+
+	first := logs[0]
+	if first.Message != "connected to primary" || first.Level != slog.LevelInfo {
+		t.Errorf("Expected the info message without its header, got %s %q", first.Level, first.Message)
+	}
+	if v, ok := first.Find(LogPrefixKey); !ok || v.String() != "[db]" {
+		t.Errorf("Expected the prefix attribute, got %v", v)
+	}
+	if v, ok := first.Find(slog.SourceKey); !ok || !strings.HasPrefix(v.String(), "stdlog_test.go:") {
+		t.Errorf("Expected the source attribute, got %v", v)
+	}
+	if logs[1].Message != "connection lost" || logs[1].Level != slog.LevelError {
+		t.Errorf("Expected the parsed error level, got %s %q", logs[1].Level, logs[1].Message)
+	}
+	if logs[2].Message != "from slog" || logs[2].Level != slog.LevelWarn {
+		t.Errorf("Expected the slog record, got %s %q", logs[2].Level, logs[2].Message)
+	}
+
+	t.Run("Restore", func(t *testing.T) {
+		if slog.Default() != previous {
+			t.Error("Expected the previous slog default")
+		}
+		if log.Writer() != output || log.Flags() != flags || log.Prefix() != prefix {
+			t.Error("Expected the previous log output, flags and prefix")
+		}
+
+		restore()
+		if slog.Default() != previous {
+			t.Error("Expected a second restore to do nothing")
+		}
+	})
+
+	t.Run("Level", func(t *testing.T) {
+		var buf bytes.Buffer
+		collector := NewLogCollector(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		restore := CaptureDefault(collector, WithCaptureLevel(slog.LevelDebug))
+		defer restore()
+
+		log.Print("noisy")
+		if n := len(collector.GetLogs()); n != 0 {
+			t.Errorf("Expected the disabled level to be dropped, got %d records", n)
+		}
+	})
+}
+
+func TestParseStdLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		flags    int
+		prefix   string
+		expected string
+		attrs    int
+	}{
+		{"NoHeader", "hello", 0, "", "hello", 0},
+		{"Time", "2025/01/02 15:04:05 hello", log.LstdFlags, "", "hello", 0},
+		{"Microseconds", "15:04:05.123456 hello", log.Lmicroseconds, "", "hello", 0},
+		{"Source", "2025/01/02 main.go:12: hello", log.Ldate | log.Lshortfile, "", "hello", 1},
+		{"LongSource", "/src/app/main.go:12: a: b", log.Llongfile, "", "a: b", 1},
+		{"Prefix", "app: 15:04:05 hello", log.Ltime, "app: ", "hello", 1},
+		{"MsgPrefix", "15:04:05 app: hello", log.Ltime | log.Lmsgprefix, "app: ", "hello", 1},
+		{"Mismatch", "written directly", log.LstdFlags, "", "written directly", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, attrs := parseStdLine(tt.line, tt.flags, tt.prefix)
+			if msg != tt.expected || len(attrs) != tt.attrs {
+				t.Errorf("Expected %q with %d attrs, got %q with %v", tt.expected, tt.attrs, msg, attrs)
+			}
+		})
+	}
+}
+
+func TestParseStdLevel(t *testing.T) {
+	tests := []struct {
+		msg      string
+		level    slog.Level
+		expected string
+	}{
+		{"ERROR: failed", slog.LevelError, "failed"},
+		{"[warn] retrying", slog.LevelWarn, "retrying"},
+		{"Warning: disk low", slog.LevelWarn, "disk low"},
+		{"[DEBUG]", slog.LevelDebug, ""},
+		{"note: not a level", slog.LevelInfo, "note: not a level"},
+		{"error connecting", slog.LevelInfo, "error connecting"},
+		{"[unclosed", slog.LevelInfo, "[unclosed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			level, msg := parseStdLevel(tt.msg, slog.LevelInfo)
+			if level != tt.level || msg != tt.expected {
+				t.Errorf("Expected %s %q, got %s %q", tt.level, tt.expected, level, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"cmp"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// duplicateOf returns the index of the stored record that the new record repeats,
+// or -1 if there is none. The caller must hold the write lock.
+func (s *MemStorage) duplicateOf(record *Record) int {
+	if len(s.records) == 0 {
+		return -1
+	}
+	if s.dedupWindow == 0 {
+		last := len(s.records) - 1
+		if sameRecord(&s.records[last], record) {
+			return last
+		}
+		return -1
+	}
+
+	cutoff := record.Time.Add(-s.dedupWindow)
+	for i := len(s.records) - 1; i >= 0 && !s.records[i].Time.Before(cutoff); i-- {
+		if sameRecord(&s.records[i], record) {
+			return i
+		}
+	}
+	return -1
+}
+
+// collapseInto collapses a repeated record into the stored record with the sequence
+// number, and reports whether the record is still stored
+func (s *MemStorage) collapseInto(seq uint64, repeat *Record) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, found := slices.BinarySearchFunc(s.records, seq, func(r Record, target uint64) int {
+		return cmp.Compare(r.Seq, target)
+	})
+	if !found {
+		return false
+	}
+	s.records[i].collapse(repeat)
+	return true
+}
+
+// collapse adds the occurrences of a repeated record to r
+func (r *Record) collapse(repeat *Record) {
+	r.Count = r.Occurrences() + repeat.Occurrences()
+	last := repeat.LastSeen
+	if last.IsZero() {
+		last = repeat.Time
+	}
+	if last.After(r.LastSeen) {
+		r.LastSeen = last
+	}
+}
+
+// RemoveOccurrences removes the records with the sequence numbers in seen, like
+// Remove, but only the number of occurrences seen of each. A deduplicated record
+// that collapsed more occurrences since it was read is kept with the rest, timed
+// when last seen, so that nothing repeated during a Drain is lost.
+func (s *MemStorage) RemoveOccurrences(seen map[uint64]int) int {
+	if len(seen) == 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.records)
+	s.records = slices.DeleteFunc(s.records, func(r Record) bool {
+		n, ok := seen[r.Seq]
+		return ok && r.Occurrences() <= n
+	})
+	for i := range s.records {
+		r := &s.records[i]
+		if n, ok := seen[r.Seq]; ok {
+			r.split(n)
+		}
+	}
+	removed := before - len(s.records)
+	s.noteRemoved(removed)
+	return removed
+}
+
+// split drops the first n occurrences of a deduplicated record, keeping the rest.
+// Only the time of the last occurrence is known, so the rest start at it.
+func (r *Record) split(n int) {
+	r.Count = r.Occurrences() - n
+	r.Time = r.LastSeen
+	if r.Count == 1 {
+		r.Count = 0
+		r.LastSeen = time.Time{}
+	}
+}
+
+// Occurrences returns the number of times the record was logged: its Count, or one
+// for a record that was not deduplicated
+func (r *Record) Occurrences() int {
+	return max(r.Count, 1)
+}
+
+// sameRecord reports whether two records have the same level, message, journal,
+// attributes and context attributes
+func sameRecord(a, b *Record) bool {
+	if a.Level != b.Level || a.Message != b.Message || len(a.Journal) != len(b.Journal) {
+		return false
+	}
+	if !attrsEqual(a.Context, b.Context) {
+		return false
+	}
+	for i := range a.Journal {
+		x, y := &a.Journal[i], &b.Journal[i]
+		if x.Type != y.Type || x.Group != y.Group || !attrsEqual(x.Attrs, y.Attrs) {
+			return false
+		}
+	}
+	return attrsEqual(a.Attrs, b.Attrs)
+}
+
+// attrsEqual compares attributes by key and value, recursively within groups
+func attrsEqual(a, b []slog.Attr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || !valueEqual(a[i].Value, b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueEqual compares two values. Unlike slog.Value.Equal it does not panic on
+// values of uncomparable types, such as slices, which it compares deeply.
+func valueEqual(a, b slog.Value) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+	switch a.Kind() {
+	case slog.KindGroup:
+		return attrsEqual(a.Group(), b.Group())
+	case slog.KindAny, slog.KindLogValuer:
+		return reflect.DeepEqual(a.Any(), b.Any())
+	default:
+		return a.Equal(b)
+	}
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWithDedup(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	record := func(offset time.Duration, msg string, attrs ...slog.Attr) *Record {
+		return &Record{Time: base.Add(offset), Level: slog.LevelWarn, Message: msg, Attrs: attrs}
+	}
+
+	t.Run("Consecutive", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(0))
+		store.Append(record(0, "retrying", slog.Int("attempt", 1)))
+		store.Append(record(time.Second, "retrying", slog.Int("attempt", 1)))
+		store.Append(record(2*time.Second, "retrying", slog.Int("attempt", 1)))
+		store.Append(record(3*time.Second, "retrying", slog.Int("attempt", 2)))
+		store.Append(record(4*time.Second, "connected"))
+		store.Append(record(5*time.Second, "retrying", slog.Int("attempt", 2)))
+
+		records := store.GetAll()
+		if len(records) != 4 {
+			t.Fatalf("Expected 4 records, got %d", len(records))
+		}
+		first := records[0]
+		if first.Count != 3 || first.Occurrences() != 3 {
+			t.Errorf("Expected count 3, got %d", first.Count)
+		}
+		if !first.Time.Equal(base) || !first.LastSeen.Equal(base.Add(2*time.Second)) {
+			t.Errorf("Expected first and last seen at 0s and 2s, got %v and %v", first.Time, first.LastSeen)
+		}
+		if records[1].Count != 0 || records[1].Occurrences() != 1 {
+			t.Errorf("Expected a single occurrence, got count %d", records[1].Count)
+		}
+		if records[3].Seq != 4 {
+			t.Errorf("Expected collapsed records not to use sequence numbers, got %d", records[3].Seq)
+		}
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(10 * time.Second))
+		store.Append(record(0, "retrying"))
+		store.Append(record(time.Second, "connected"))
+		store.Append(record(5*time.Second, "retrying"))
+		store.Append(record(11*time.Second, "retrying"))
+
+		records := store.GetAll()
+		if len(records) != 3 {
+			t.Fatalf("Expected 3 records, got %d", len(records))
+		}
+		if records[0].Count != 2 {
+			t.Errorf("Expected the repeat within the window to be collapsed, got count %d", records[0].Count)
+		}
+		if records[2].Count != 0 || records[2].Message != "retrying" {
+			t.Errorf("Expected a new record after the window, got %+v", records[2])
+		}
+	})
+
+	t.Run("ComparesJournal", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(0))
+		withUser := func(user string) *Record {
+			r := record(0, "request")
+			r.Journal = OperationJournal{
+				{Type: OpGroup, Group: "api"},
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.String("user", user)}},
+			}
+			return r
+		}
+		store.Append(withUser("alice"))
+		store.Append(withUser("alice"))
+		store.Append(withUser("bob"))
+
+		if records := store.GetAll(); len(records) != 2 || records[0].Count != 2 {
+			t.Errorf("Expected alice collapsed and bob kept, got %d records", len(records))
+		}
+	})
+
+	t.Run("ComparesContext", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(0))
+		withTrace := func(id string) *Record {
+			r := record(0, "retrying")
+			r.Context = []slog.Attr{slog.String("trace_id", id)}
+			return r
+		}
+		store.Append(withTrace("a"))
+		store.Append(withTrace("a"))
+		store.Append(withTrace("b"))
+
+		if records := store.GetAll(); len(records) != 2 || records[0].Count != 2 {
+			t.Errorf("Expected records from other traces kept apart, got %d records", len(records))
+		}
+	})
+
+	t.Run("UncomparableValues", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(0))
+		store.Append(record(0, "batch", slog.Any("ids", []int{1, 2})))
+		store.Append(record(0, "batch", slog.Any("ids", []int{1, 2})))
+		store.Append(record(0, "batch", slog.Any("ids", []int{3})))
+
+		if records := store.GetAll(); len(records) != 2 || records[0].Count != 2 {
+			t.Errorf("Expected equal slices collapsed, got %d records", len(records))
+		}
+	})
+
+	t.Run("AddsCounts", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(0))
+		first := record(0, "retrying")
+		first.Count, first.LastSeen = 2, base.Add(time.Second)
+		second := record(2*time.Second, "retrying")
+		second.Count, second.LastSeen = 3, base.Add(4*time.Second)
+		store.Append(first)
+		store.Append(second)
+
+		records := store.GetAll()
+		if records[0].Count != 5 || !records[0].LastSeen.Equal(base.Add(4*time.Second)) {
+			t.Errorf("Expected count 5 last seen at 4s, got %d at %v", records[0].Count, records[0].LastSeen)
+		}
+	})
+
+	t.Run("RemoveOccurrences", func(t *testing.T) {
+		store := NewRecordStorage(WithDedup(0))
+		for i := range 3 {
+			store.Append(record(time.Duration(i)*time.Second, "retrying"))
+		}
+		store.Append(record(3*time.Second, "connected"))
+		store.Append(record(4*time.Second, "done"))
+
+		// The first record was read with 2 occurrences and has 3 now
+		removed := store.RemoveOccurrences(map[uint64]int{1: 2, 2: 1})
+		records := store.GetAll()
+		if removed != 1 || len(records) != 2 {
+			t.Fatalf("Expected 1 record removed and 2 left, got %d and %v", removed, records)
+		}
+		if kept := records[0]; kept.Seq != 1 || kept.Occurrences() != 1 || !kept.Time.Equal(base.Add(2*time.Second)) || !kept.LastSeen.IsZero() {
+			t.Errorf("Expected the last occurrence kept as a single record, got %+v", kept)
+		}
+		if records[1].Message != "done" {
+			t.Errorf("Expected unseen records kept, got %+v", records[1])
+		}
+	})
+
+	t.Run("Realize", func(t *testing.T) {
+		r := record(0, "retrying")
+		r.Count, r.LastSeen = 2, base.Add(time.Second)
+		if realized := r.Realize(); realized.Count != 2 || !realized.LastSeen.Equal(r.LastSeen) {
+			t.Errorf("Expected Realize to keep the count, got %d", realized.Count)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// KV is an attribute with its group path joined into the key, such as "api.user"
+type KV struct {
+	Key   string
+	Value slog.Value
+}
+
+// DuplicatePolicy controls how Flatten and AsMap handle a key that appears more than
+// once in a record, such as an attribute added both by With and at the call site.
+type DuplicatePolicy int
+
+const (
+	// DuplicateLastWins keeps only the last value for a key, as a JSON decoder would.
+	DuplicateLastWins DuplicatePolicy = iota
+	// DuplicateKeepAll keeps every value. Flatten returns each one; AsMap collects
+	// them into a []any.
+	DuplicateKeepAll
+	// DuplicateSuffix keeps every value, renaming the later ones with a numeric
+	// suffix: user, user_2, user_3.
+	DuplicateSuffix
+)
+
+// FlattenOption configures Flatten and AsMap
+type FlattenOption func(*flattenConfig)
+
+type flattenConfig struct {
+	duplicates DuplicatePolicy
+}
+
+// WithDuplicates sets how keys that appear more than once are handled. The default
+// is DuplicateLastWins.
+func WithDuplicates(policy DuplicatePolicy) FlattenOption {
+	return func(cfg *flattenConfig) {
+		cfg.duplicates = policy
+	}
+}
+
+func newFlattenConfig(opts []FlattenOption) *flattenConfig {
+	cfg := &flattenConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Flatten returns the record's attributes, including those from the journal, with
+// groups resolved into keys joined by sep:
+//
+//	logger.WithGroup("api").Info("request", "user", "123")
+//	record.Flatten(".") // [{api.user 123}]
+//
+// Values are resolved, groups with an empty key are inlined, and empty attributes
+// and groups are skipped, as slog handlers do. Attributes keep their order.
+func (r *Record) Flatten(sep string, opts ...FlattenOption) []KV {
+	cfg := newFlattenConfig(opts)
+	realized := r.Realize()
+	kvs := appendFlattened(realized.Attrs, "", sep, nil)
+
+	switch cfg.duplicates {
+	case DuplicateLastWins:
+		return lastWins(kvs)
+	case DuplicateSuffix:
+		return suffixDuplicates(kvs)
+	default:
+		return kvs
+	}
+}
+
+// appendFlattened appends the attributes to result, prefixing keys with the group path
+func appendFlattened(attrs []slog.Attr, prefix, sep string, result []KV) []KV {
+	for _, attr := range attrs {
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+
+		key := attr.Key
+		if prefix != "" && key != "" {
+			key = prefix + sep + key
+		} else if key == "" {
+			key = prefix
+		}
+
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			result = appendFlattened(value.Group(), key, sep, result)
+			continue
+		}
+		result = append(result, KV{Key: key, Value: value})
+	}
+	return result
+}
+
+// lastWins removes all but the last occurrence of each key
+func lastWins(kvs []KV) []KV {
+	last := make(map[string]int, len(kvs))
+	for i, kv := range kvs {
+		last[kv.Key] = i
+	}
+	if len(last) == len(kvs) {
+		return kvs
+	}
+
+	result := make([]KV, 0, len(last))
+	for i, kv := range kvs {
+		if last[kv.Key] == i {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+// suffixDuplicates renames repeated keys with a numeric suffix that does not clash
+// with any other key
+func suffixDuplicates(kvs []KV) []KV {
+	used := make(map[string]bool, len(kvs))
+	for _, kv := range kvs {
+		used[kv.Key] = true
+	}
+
+	seen := make(map[string]int, len(kvs))
+	for i, kv := range kvs {
+		seen[kv.Key]++
+		if seen[kv.Key] == 1 {
+			continue
+		}
+		for n := seen[kv.Key]; ; n++ {
+			key := kv.Key + "_" + strconv.Itoa(n)
+			if !used[key] {
+				used[key] = true
+				kvs[i].Key = key
+				break
+			}
+		}
+	}
+	return kvs
+}
+
+// AsMap returns the record's attributes, including those from the journal, as
+// nested maps: each group becomes a map[string]any and other values are converted
+// with slog.Value.Any. Groups with the same key are merged, and repeated keys within
+// a map are handled by the duplicate policy.
+func (r *Record) AsMap(opts ...FlattenOption) map[string]any {
+	cfg := newFlattenConfig(opts)
+	realized := r.Realize()
+	root := newMapNode()
+	root.add(realized.Attrs)
+	return root.build(cfg.duplicates)
+}
+
+// mapNode collects the values for each key of one map, in the order the keys were
+// first seen, so duplicates can be resolved once all attributes have been added
+type mapNode struct {
+	keys   []string
+	values map[string][]any // each value is either a *mapNode or a plain value
+}
+
+func newMapNode() *mapNode {
+	return &mapNode{values: make(map[string][]any)}
+}
+
+// add adds the attributes, inlining groups with an empty key and merging groups into
+// an existing group with the same key
+func (n *mapNode) add(attrs []slog.Attr) {
+	for _, attr := range attrs {
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+
+		value := attr.Value.Resolve()
+		if value.Kind() != slog.KindGroup {
+			n.set(attr.Key, value.Any())
+			continue
+		}
+		if attr.Key == "" {
+			n.add(value.Group())
+			continue
+		}
+
+		group := n.group(attr.Key)
+		if group == nil {
+			group = newMapNode()
+			group.add(value.Group())
+			if len(group.keys) > 0 {
+				n.set(attr.Key, group)
+			}
+			continue
+		}
+		group.add(value.Group())
+	}
+}
+
+// group returns the last group stored under key, or nil if there is none
+func (n *mapNode) group(key string) *mapNode {
+	values := n.values[key]
+	for i := len(values) - 1; i >= 0; i-- {
+		if group, ok := values[i].(*mapNode); ok {
+			return group
+		}
+	}
+	return nil
+}
+
+func (n *mapNode) set(key string, v any) {
+	if _, ok := n.values[key]; !ok {
+		n.keys = append(n.keys, key)
+	}
+	n.values[key] = append(n.values[key], v)
+}
+
+// build converts the node to a map, applying the policy to repeated keys
+func (n *mapNode) build(policy DuplicatePolicy) map[string]any {
+	result := make(map[string]any, len(n.keys))
+	for _, key := range n.keys {
+		values := n.values[key]
+		for i, v := range values {
+			if group, ok := v.(*mapNode); ok {
+				values[i] = group.build(policy)
+			}
+		}
+
+		switch {
+		case len(values) == 1:
+			result[key] = values[0]
+		case policy == DuplicateKeepAll:
+			result[key] = values
+		case policy == DuplicateSuffix:
+			result[key] = values[0]
+			for i, v := range values[1:] {
+				result[n.suffixed(key, i+2, result)] = v
+			}
+		default:
+			result[key] = values[len(values)-1]
+		}
+	}
+	return result
+}
+
+// suffixed returns key with the lowest numeric suffix from start that is neither a
+// key of the node nor already used in result
+func (n *mapNode) suffixed(key string, start int, result map[string]any) string {
+	for i := start; ; i++ {
+		candidate := key + "_" + strconv.Itoa(i)
+		_, taken := n.values[candidate]
+		_, used := result[candidate]
+		if !taken && !used {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// flatKeys returns the keys of the flattened attributes, in order
+func flatKeys(kvs []KV) []string {
+	keys := make([]string, len(kvs))
+	for i, kv := range kvs {
+		keys[i] = kv.Key
+	}
+	return keys
+}
+
+func TestFlatten(t *testing.T) {
+	t.Run("Groups", func(t *testing.T) {
+		record := &Record{
+			Attrs: []slog.Attr{
+				slog.String("a", "1"),
+				slog.Group("g", slog.Group("h", slog.Int("b", 2)), slog.Group("", slog.Int("c", 3))),
+				{},
+				slog.Group("empty"),
+			},
+		}
+
+		got := record.Flatten("_")
+		expected := []string{"a", "g_h_b", "g_c"}
+		if !reflect.DeepEqual(flatKeys(got), expected) {
+			t.Fatalf("Expected keys %v, got %v", expected, flatKeys(got))
+		}
+		if got[1].Value.Int64() != 2 {
+			t.Errorf("Expected g_h_b to be 2, got %v", got[1].Value)
+		}
+	})
+
+	t.Run("Journal", func(t *testing.T) {
+		record := &Record{
+			Attrs: []slog.Attr{slog.String("user", "alice")},
+			Journal: OperationJournal{
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.String("service", "auth")}},
+				{Type: OpGroup, Group: "api"},
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.String("version", "v1")}},
+			},
+		}
+
+		got := record.Flatten(".")
+		expected := []string{"service", "api.version", "api.user"}
+		if !reflect.DeepEqual(flatKeys(got), expected) {
+			t.Errorf("Expected keys %v, got %v", expected, flatKeys(got))
+		}
+	})
+
+	t.Run("ResolvesLogValuers", func(t *testing.T) {
+		record := &Record{
+			Attrs: []slog.Attr{slog.Any("req", groupValuer{})},
+		}
+
+		got := record.Flatten(".")
+		if len(got) != 1 || got[0].Key != "req.x" || got[0].Value.Int64() != 1 {
+			t.Errorf("Expected resolved group attributes, got %v", got)
+		}
+	})
+
+	duplicates := &Record{
+		Attrs: []slog.Attr{
+			slog.String("user", "alice"),
+			slog.String("user_2", "taken"),
+			slog.String("user", "bob"),
+			slog.String("user", "carol"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   DuplicatePolicy
+		expected []KV
+	}{
+		{
+			name:   "LastWins",
+			policy: DuplicateLastWins,
+			expected: []KV{
+				{Key: "user_2", Value: slog.StringValue("taken")},
+				{Key: "user", Value: slog.StringValue("carol")},
+			},
+		},
+		{
+			name:   "KeepAll",
+			policy: DuplicateKeepAll,
+			expected: []KV{
+				{Key: "user", Value: slog.StringValue("alice")},
+				{Key: "user_2", Value: slog.StringValue("taken")},
+				{Key: "user", Value: slog.StringValue("bob")},
+				{Key: "user", Value: slog.StringValue("carol")},
+			},
+		},
+		{
+			name:   "Suffix",
+			policy: DuplicateSuffix,
+			expected: []KV{
+				{Key: "user", Value: slog.StringValue("alice")},
+				{Key: "user_2", Value: slog.StringValue("taken")},
+				{Key: "user_3", Value: slog.StringValue("bob")},
+				{Key: "user_4", Value: slog.StringValue("carol")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := duplicates.Flatten(".", WithDuplicates(tt.policy))
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i, kv := range tt.expected {
+				if got[i].Key != kv.Key || !got[i].Value.Equal(kv.Value) {
+					t.Errorf("Attr %d: expected %v, got %v", i, kv, got[i])
+				}
+			}
+		})
+	}
+
+	t.Run("DefaultIsLastWins", func(t *testing.T) {
+		got := duplicates.Flatten(".")
+		if len(got) != 2 || got[1].Value.String() != "carol" {
+			t.Errorf("Expected the last value to win, got %v", got)
+		}
+	})
+}
+
+func TestAsMap(t *testing.T) {
+	t.Run("NestedGroups", func(t *testing.T) {
+		record := &Record{
+			Attrs: []slog.Attr{
+				slog.Int("status", 200),
+				slog.Group("", slog.Bool("inline", true)),
+				slog.Group("empty"),
+			},
+			Journal: OperationJournal{
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.String("service", "auth")}},
+				{Type: OpGroup, Group: "api"},
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.Group("req", slog.String("method", "GET"))}},
+			},
+		}
+
+		expected := map[string]any{
+			"service": "auth",
+			"api": map[string]any{
+				"req":    map[string]any{"method": "GET"},
+				"status": int64(200),
+				"inline": true,
+			},
+		}
+		if got := record.AsMap(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("MergesGroups", func(t *testing.T) {
+		record := &Record{
+			Attrs: []slog.Attr{
+				slog.Group("req", slog.String("method", "GET")),
+				slog.Group("req", slog.String("path", "/users")),
+			},
+		}
+
+		expected := map[string]any{
+			"req": map[string]any{"method": "GET", "path": "/users"},
+		}
+		if got := record.AsMap(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	})
+
+	record := &Record{
+		Attrs: []slog.Attr{
+			slog.String("user", "alice"),
+			slog.Any("tags", []any{"a", "b"}),
+			slog.Group("req", slog.String("id", "1"), slog.String("id", "2")),
+			slog.String("user", "bob"),
+			slog.Any("tags", []any{"c"}),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		policy   DuplicatePolicy
+		expected map[string]any
+	}{
+		{
+			name:   "LastWins",
+			policy: DuplicateLastWins,
+			expected: map[string]any{
+				"user": "bob",
+				"tags": []any{"c"},
+				"req":  map[string]any{"id": "2"},
+			},
+		},
+		{
+			name:   "KeepAll",
+			policy: DuplicateKeepAll,
+			expected: map[string]any{
+				"user": []any{"alice", "bob"},
+				"tags": []any{[]any{"a", "b"}, []any{"c"}},
+				"req":  map[string]any{"id": []any{"1", "2"}},
+			},
+		},
+		{
+			name:   "Suffix",
+			policy: DuplicateSuffix,
+			expected: map[string]any{
+				"user":   "alice",
+				"user_2": "bob",
+				"tags":   []any{"a", "b"},
+				"tags_2": []any{"c"},
+				"req":    map[string]any{"id": "1", "id_2": "2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := record.AsMap(WithDuplicates(tt.policy))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		got := (&Record{}).AsMap()
+		if got == nil || len(got) != 0 {
+			t.Errorf("Expected an empty map, got %v", got)
+		}
+	})
+}
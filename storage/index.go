@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// attrIndex maps the values of one attribute path to the sequence numbers of
+// the records carrying them, in append order.
+type attrIndex struct {
+	path     []string
+	postings map[string][]uint64
+}
+
+func newAttrIndex(path string) *attrIndex {
+	return &attrIndex{
+		path:     strings.Split(path, "."),
+		postings: make(map[string][]uint64),
+	}
+}
+
+// add indexes a realized record
+func (idx *attrIndex) add(realized *Record) {
+	if v, ok := realized.Find(idx.path...); ok {
+		key := v.String()
+		idx.postings[key] = append(idx.postings[key], realized.Seq)
+	}
+}
+
+// indexValue converts a lookup value to the key used in the postings
+func indexValue(value any) string {
+	return slog.AnyValue(value).Resolve().String()
+}
+
+// indexRecord adds the record to every index. The caller must hold the write lock.
+func (s *MemStorage) indexRecord(record *Record) {
+	if len(s.indexes) == 0 {
+		return
+	}
+	realized := record.Realize()
+	for _, idx := range s.indexes {
+		idx.add(&realized)
+	}
+}
+
+// noteRemoved accounts for records removed from storage. Index entries for removed
+// records are skipped during lookup and dropped once they outnumber the stored
+// records, which keeps the cost of cleanup constant per removed record.
+// The caller must hold the write lock.
+func (s *MemStorage) noteRemoved(n int) {
+	if len(s.indexes) == 0 || n <= 0 {
+		return
+	}
+	s.indexStale += n
+	if s.indexStale > len(s.records) {
+		s.rebuildIndexes()
+	}
+}
+
+// rebuildIndexes recreates every index from the stored records.
+// The caller must hold the write lock.
+func (s *MemStorage) rebuildIndexes() {
+	for path := range s.indexes {
+		s.indexes[path] = newAttrIndex(path)
+	}
+	for i := range s.records {
+		s.indexRecord(&s.records[i])
+	}
+	s.indexStale = 0
+}
+
+// Lookup returns the records whose realized attribute at the dotted path, such as
+// "http.request_id", has the given value, in append order. Values are compared by
+// their text, so Lookup("user", 123) and Lookup("user", "123") are equivalent.
+//
+// Paths declared with WithIndex are answered from the index; other paths fall back
+// to scanning every record.
+func (s *MemStorage) Lookup(path string, value any) []Record {
+	key := indexValue(value)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.indexes[path]
+	if !ok {
+		return s.scan(strings.Split(path, "."), key)
+	}
+
+	result := make([]Record, 0, len(idx.postings[key]))
+	for _, seq := range idx.postings[key] {
+		i := s.indexAfter(seq - 1)
+		if i < len(s.records) && s.records[i].Seq == seq {
+			result = append(result, s.records[i])
+		}
+	}
+	return result
+}
+
+// scan finds matching records without an index. The caller must hold the read lock.
+func (s *MemStorage) scan(path []string, key string) []Record {
+	result := make([]Record, 0)
+	for _, record := range s.records {
+		realized := record.Realize()
+		if v, ok := realized.Find(path...); ok && v.String() == key {
+			result = append(result, record)
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func requestRecord(id string, msg string) *Record {
+	return &Record{
+		Message: msg,
+		Attrs:   []slog.Attr{slog.String("request_id", id)},
+		Journal: OperationJournal{{Type: OpGroup, Group: "http"}},
+	}
+}
+
+func messages(records []Record) []string {
+	result := make([]string, len(records))
+	for i, r := range records {
+		result[i] = r.Message
+	}
+	return result
+}
+
+func TestLookup(t *testing.T) {
+	t.Run("IndexedPath", func(t *testing.T) {
+		store := NewRecordStorage(WithIndex("http.request_id"))
+		store.Append(requestRecord("a", "1"))
+		store.Append(requestRecord("b", "2"))
+		store.Append(requestRecord("a", "3"))
+
+		got := messages(store.Lookup("http.request_id", "a"))
+		if fmt.Sprint(got) != "[1 3]" {
+			t.Errorf("Expected [1 3], got %v", got)
+		}
+
+		if got := store.Lookup("http.request_id", "missing"); len(got) != 0 {
+			t.Errorf("Expected no records, got %v", got)
+		}
+	})
+
+	t.Run("ReturnsRawRecords", func(t *testing.T) {
+		store := NewRecordStorage(WithIndex("http.request_id"))
+		store.Append(requestRecord("a", "1"))
+
+		got := store.Lookup("http.request_id", "a")
+		if len(got) != 1 || len(got[0].Journal) != 1 || got[0].Attrs[0].Key != "request_id" {
+			t.Errorf("Expected the raw stored record, got %v", got)
+		}
+	})
+
+	t.Run("ValueComparedAsText", func(t *testing.T) {
+		store := NewRecordStorage(WithIndex("user"))
+		store.Append(&Record{Message: "int", Attrs: []slog.Attr{slog.Int("user", 123)}})
+		store.Append(&Record{Message: "string", Attrs: []slog.Attr{slog.String("user", "123")}})
+
+		if got := messages(store.Lookup("user", 123)); fmt.Sprint(got) != "[int string]" {
+			t.Errorf("Expected [int string], got %v", got)
+		}
+	})
+
+	t.Run("UnindexedPathScans", func(t *testing.T) {
+		store := NewRecordStorage()
+		store.Append(requestRecord("a", "1"))
+		store.Append(requestRecord("b", "2"))
+
+		if got := messages(store.Lookup("http.request_id", "b")); fmt.Sprint(got) != "[2]" {
+			t.Errorf("Expected [2], got %v", got)
+		}
+	})
+
+	t.Run("MaintainedOnCleanup", func(t *testing.T) {
+		store := NewRecordStorage(WithIndex("http.request_id"), WithMaxSize(3))
+		for i := range 10 {
+			store.Append(requestRecord(fmt.Sprint(i%2), fmt.Sprint(i)))
+		}
+
+		if got := messages(store.Lookup("http.request_id", "1")); fmt.Sprint(got) != "[7 9]" {
+			t.Errorf("Expected [7 9], got %v", got)
+		}
+		if got := messages(store.Lookup("http.request_id", "0")); fmt.Sprint(got) != "[8]" {
+			t.Errorf("Expected [8], got %v", got)
+		}
+
+		store.mu.RLock()
+		stale := store.indexStale
+		store.mu.RUnlock()
+		if stale > 3 {
+			t.Errorf("Expected stale entries to be compacted, got %d", stale)
+		}
+	})
+
+	t.Run("MaintainedOnRemove", func(t *testing.T) {
+		store := NewRecordStorage(WithIndex("http.request_id"))
+		store.Append(requestRecord("a", "1"))
+		store.Append(requestRecord("a", "2"))
+		store.Remove(1)
+
+		if got := messages(store.Lookup("http.request_id", "a")); fmt.Sprint(got) != "[2]" {
+			t.Errorf("Expected [2], got %v", got)
+		}
+
+		store.TakeAll()
+		if got := store.Lookup("http.request_id", "a"); len(got) != 0 {
+			t.Errorf("Expected no records after TakeAll, got %v", got)
+		}
+
+		store.Append(requestRecord("a", "3"))
+		if got := messages(store.Lookup("http.request_id", "a")); fmt.Sprint(got) != "[3]" {
+			t.Errorf("Expected [3], got %v", got)
+		}
+	})
+
+	t.Run("MultipleIndexes", func(t *testing.T) {
+		store := NewRecordStorage(WithIndex("http.request_id"), WithIndex("service"))
+		store.Append(&Record{
+			Message: "both",
+			Attrs:   []slog.Attr{slog.String("service", "api"), slog.Group("http", slog.String("request_id", "x"))},
+		})
+
+		if got := store.Lookup("service", "api"); len(got) != 1 {
+			t.Errorf("Expected 1 record by service, got %d", len(got))
+		}
+		if got := store.Lookup("http.request_id", "x"); len(got) != 1 {
+			t.Errorf("Expected 1 record by request_id, got %d", len(got))
+		}
+	})
+}
+
+func BenchmarkLookup(b *testing.B) {
+	for _, indexed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("Indexed=%v", indexed), func(b *testing.B) {
+			var opts []Option
+			if indexed {
+				opts = append(opts, WithIndex("http.request_id"))
+			}
+			store := NewRecordStorage(opts...)
+			for i := range 10000 {
+				store.Append(requestRecord(fmt.Sprint(i%1000), "msg"))
+			}
+
+			b.ResetTimer()
+			for b.Loop() {
+				store.Lookup("http.request_id", "500")
+			}
+		})
+	}
+}
@@ -37,3 +37,13 @@ type Operation struct {
 // Without this journal, attributes could be incorrectly grouped during replay,
 // causing "global=value" to become "group.global=value".
 type OperationJournal []Operation
+
+// hasGroups reports whether the journal opens a group
+func (j OperationJournal) hasGroups() bool {
+	for _, op := range j {
+		if op.Type == OpGroup && op.Group != "" {
+			return true
+		}
+	}
+	return false
+}
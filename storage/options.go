@@ -32,6 +32,21 @@ func WithMaxAge(maxAge time.Duration) Option {
 	return WithCleanupFunc(maxAgeCleanup(maxAge))
 }
 
+// WithDedup collapses a record into an earlier stored record with the same level,
+// message and attributes, incrementing its Count and updating LastSeen instead of
+// storing it again. With a window of zero only the most recent record is compared,
+// collapsing consecutive repeats; otherwise any record first seen within the window
+// before the new one is compared.
+//
+// Collapsing updates a record in place, so readers that track new records by
+// sequence number do not see the updated count until they read it again.
+func WithDedup(window time.Duration) Option {
+	return func(rs *MemStorage) {
+		rs.dedup = true
+		rs.dedupWindow = window
+	}
+}
+
 // WithCleanupFunc allows setting a custom cleanup function.
 func WithCleanupFunc(cleanupFn CleanupFunc) Option {
 	return func(rs *MemStorage) {
@@ -59,3 +74,23 @@ func WithDebounceTime(duration time.Duration) Option {
 		}
 	}
 }
+
+// WithSequenceStart sets the sequence number after which Append starts numbering records.
+// This lets a fresh store continue numbering after a checkpoint persisted by a previous process.
+func WithSequenceStart(seq uint64) Option {
+	return func(rs *MemStorage) {
+		rs.lastSeq = seq
+	}
+}
+
+// WithIndex maintains an index on the realized attribute at the dotted path, such as
+// "http.request_id", so that Lookup on that path does not scan every record.
+// It can be given more than once to index several paths.
+func WithIndex(path string) Option {
+	return func(rs *MemStorage) {
+		if rs.indexes == nil {
+			rs.indexes = make(map[string]*attrIndex)
+		}
+		rs.indexes[path] = newAttrIndex(path)
+	}
+}
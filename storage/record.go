@@ -6,14 +6,25 @@ import (
 	"time"
 )
 
-// Record represents a log Record that can be stored, somewhere.
+// Record represents a log Record that can be stored, somewhere. Stored records keep
+// the attributes added with WithAttrs and WithGroup in Journal; realized records, as
+// returned by Realize and LogCollector.GetLogs, have them applied to Attrs and no
+// Journal.
 type Record struct {
+	Seq     uint64 // sequence number assigned by the storage on Append
 	Time    time.Time
 	Level   slog.Level
 	Message string
 	PC      uintptr // Program counter for call site information
 	Attrs   []slog.Attr
 	Journal OperationJournal // journal of handler operations for replay
+	Context []slog.Attr      // attributes captured from the context, kept apart from Attrs
+
+	// Count is the number of occurrences collapsed into the record by WithDedup,
+	// and LastSeen the time of the last one; Time is the first. Count is zero for
+	// a record that was stored once.
+	Count    int
+	LastSeen time.Time
 }
 
 // NewRecord creates a new Record from a slog.Record and journal.
@@ -43,67 +54,182 @@ func NewRecord(_ context.Context, journal OperationJournal, r *slog.Record) *Rec
 	return record
 }
 
-// Realize returns a new Record with all attributes from the journal applied.
+// Realize returns a new Record with all attributes from the journal applied, as a
+// handler would see them on replay: attributes added inside groups are nested in one
+// group per name, empty attributes are dropped, groups with an empty key are inlined,
+// and groups without attributes are dropped. The realized record has no journal, so
+// realizing it again returns the same record; code that read Journal from realized
+// records should read the nested groups in Attrs instead.
 func (r *Record) Realize() Record {
 	result := Record{
-		Time:    r.Time,
-		Level:   r.Level,
-		Message: r.Message,
-		PC:      r.PC,
-		Attrs:   make([]slog.Attr, 0),
-		Journal: r.Journal,
+		Seq:      r.Seq,
+		Time:     r.Time,
+		Level:    r.Level,
+		Message:  r.Message,
+		PC:       r.PC,
+		Context:  r.Context,
+		Count:    r.Count,
+		LastSeen: r.LastSeen,
 	}
 
-	// Apply the journal to build the attributes
-	var currentGroups []string
-	var collectorAttrs []slog.Attr
+	// levels[i] holds the attributes added while i groups were open
+	var groups []string
+	levels := make([][]slog.Attr, 1)
 
 	for _, op := range r.Journal {
 		switch op.Type {
 		case OpAttrs:
-			if len(currentGroups) > 0 {
-				// These attributes belong to the current group
-				for _, attr := range op.Attrs {
-					collectorAttrs = append(collectorAttrs, applyGroups(attr, currentGroups))
-				}
-			} else {
-				// Global attributes
-				collectorAttrs = append(collectorAttrs, op.Attrs...)
-			}
+			levels[len(groups)] = append(levels[len(groups)], op.Attrs...)
 		case OpGroup:
-			currentGroups = append(currentGroups, op.Group)
+			if op.Group == "" {
+				// WithGroup with an empty name returns the receiver
+				continue
+			}
+			groups = append(groups, op.Group)
+			levels = append(levels, nil)
 		default:
 			// Unknown operation type - ignore
 		}
 	}
 
-	// First add collector attributes (from WithAttrs)
-	result.Attrs = append(result.Attrs, collectorAttrs...)
+	// The record's own attributes belong to the innermost group
+	levels[len(groups)] = append(levels[len(groups)], r.Attrs...)
 
-	// Then add record attributes (from the log message itself)
-	// These need to be grouped based on the final group state
-	for _, attr := range r.Attrs {
-		if len(currentGroups) > 0 {
-			result.Attrs = append(result.Attrs, applyGroups(attr, currentGroups))
-		} else {
-			result.Attrs = append(result.Attrs, attr)
+	// Build from the innermost group out, leaving out groups that end up empty
+	attrs := normalizeAttrs(levels[len(groups)])
+	for i := len(groups) - 1; i >= 0; i-- {
+		outer := normalizeAttrs(levels[i])
+		if len(attrs) > 0 {
+			outer = append(outer, slog.Attr{Key: groups[i], Value: slog.GroupValue(attrs...)})
 		}
+		attrs = outer
 	}
 
+	result.Attrs = attrs
+	if result.Attrs == nil {
+		result.Attrs = make([]slog.Attr, 0)
+	}
 	return result
 }
 
-// applyGroups creates a new attribute with groups applied as nested structure
-func applyGroups(attr slog.Attr, groups []string) slog.Attr {
-	if len(groups) == 0 {
-		return attr
+// normalizeAttrs returns the attributes as a handler outputs them: empty attributes
+// are dropped, groups with an empty key are inlined, and groups without attributes
+// are dropped. LogValuers are resolved only where they produce a group.
+func normalizeAttrs(attrs []slog.Attr) []slog.Attr {
+	if !needsNormalizing(attrs) {
+		return attrs
 	}
 
-	// Build nested groups from the inside out
-	result := attr
-	for i := len(groups) - 1; i >= 0; i-- {
-		result = slog.Group(groups[i], result)
-	}
+	result := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+
+		value := attr.Value.Resolve()
+		if value.Kind() != slog.KindGroup {
+			result = append(result, attr)
+			continue
+		}
 
+		members := normalizeAttrs(value.Group())
+		switch {
+		case len(members) == 0:
+			// empty groups are not output
+		case attr.Key == "":
+			result = append(result, members...)
+		default:
+			result = append(result, slog.Attr{Key: attr.Key, Value: slog.GroupValue(members...)})
+		}
+	}
 	return result
 }
+
+// needsNormalizing reports whether any attribute is empty, a group, or a LogValuer
+// that may resolve to a group
+func needsNormalizing(attrs []slog.Attr) bool {
+	for _, attr := range attrs {
+		switch attr.Value.Kind() {
+		case slog.KindGroup, slog.KindLogValuer:
+			return true
+		}
+		if attr.Equal(slog.Attr{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the value of the attribute at the given group path, such as
+// Find("api", "user") for the attribute "user" in group "api". Attributes in
+// inline groups (groups with an empty key) are searched as if they were at the
+// level of the group. When a key appears more than once, the last one wins.
+//
+// Find searches r.Attrs only; call it on a realized record to include the
+// attributes added through the journal.
+func (r *Record) Find(path ...string) (slog.Value, bool) {
+	if len(path) == 0 {
+		return slog.Value{}, false
+	}
+	return findAttr(r.Attrs, path)
+}
+
+// FindRealized returns the value of the attribute at the path in the realized
+// record, as Find would on the result of Realize. Records whose journal opens no
+// group are searched without realizing them, as their attributes keep their paths.
+func (r *Record) FindRealized(path ...string) (slog.Value, bool) {
+	if !r.Journal.hasGroups() {
+		if v, ok := r.Find(path...); ok || len(r.Journal) == 0 {
+			return v, ok
+		}
+	}
+	realized := r.Realize()
+	return realized.Find(path...)
+}
+
+// MergeContext returns a copy of the record with the attributes captured from the
+// context moved into its journal, ahead of everything else, so that they become
+// top-level attributes like those added with WithAttrs, for readers that match or
+// replay them as ordinary attributes. Realize the result to read them in Attrs.
+func (r *Record) MergeContext() Record {
+	merged := *r
+	merged.Context = nil
+	if len(r.Context) > 0 {
+		merged.Journal = append(OperationJournal{{Type: OpAttrs, Attrs: r.Context}}, r.Journal...)
+	}
+	return merged
+}
+
+// findAttr searches attrs for the path, descending into groups.
+func findAttr(attrs []slog.Attr, path []string) (slog.Value, bool) {
+	var result slog.Value
+	found := false
+
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+
+		if attr.Key == "" && value.Kind() == slog.KindGroup {
+			if v, ok := findAttr(value.Group(), path); ok {
+				result, found = v, true
+			}
+			continue
+		}
+
+		if attr.Key != path[0] {
+			continue
+		}
+
+		if len(path) == 1 {
+			result, found = value, true
+			continue
+		}
+
+		if value.Kind() == slog.KindGroup {
+			if v, ok := findAttr(value.Group(), path[1:]); ok {
+				result, found = v, true
+			}
+		}
+	}
+
+	return result, found
+}
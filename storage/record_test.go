@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -230,13 +231,17 @@ func TestRecordRealize(t *testing.T) {
 			t.Errorf("PC not preserved")
 		}
 
-		if len(realized.Attrs) != 3 {
-			t.Fatalf("Expected 3 attributes, got %d", len(realized.Attrs))
+		// global, then one api group holding user and msg
+		if len(realized.Attrs) != 2 {
+			t.Fatalf("Expected 2 attributes, got %d", len(realized.Attrs))
 		}
 
 		if realized.Attrs[0].Key != "global" {
 			t.Errorf("Expected first attribute to be 'global', got %q", realized.Attrs[0].Key)
 		}
+		if api := realized.Attrs[1]; api.Key != "api" || len(api.Value.Group()) != 2 {
+			t.Errorf("Expected api group with 2 attributes, got %v", api)
+		}
 	})
 
 	t.Run("HandlesEmptyJournal", func(t *testing.T) {
@@ -349,12 +354,11 @@ func TestRecordRealize(t *testing.T) {
 
 		// Should have:
 		// - 2 global attrs (service, version)
-		// - 2 attrs in http group (method, path)
-		// - 1 attr in http.response group (latency)
-		// - 2 record attrs in http.response group (request_id, status)
-		// Total: 7 attributes
-		if len(realized.Attrs) != 7 {
-			t.Errorf("Expected 7 attributes, got %d", len(realized.Attrs))
+		// - 1 http group holding method, path and the response group, which
+		//   holds latency and the record attrs (request_id, status)
+		// Total: 3 top-level attributes
+		if len(realized.Attrs) != 3 {
+			t.Errorf("Expected 3 attributes, got %d", len(realized.Attrs))
 		}
 
 		// Flatten and verify structure
@@ -405,18 +409,89 @@ func TestRecordRealize(t *testing.T) {
 
 		realized := record.Realize()
 
-		// Empty group should still be applied
+		// An empty group name is ignored, as by slog handlers
 		if len(realized.Attrs) != 2 {
-			t.Errorf("Expected 2 attributes, got %d", len(realized.Attrs))
+			t.Fatalf("Expected 2 attributes, got %d", len(realized.Attrs))
 		}
 
-		// Both attrs should be within empty group
-		for _, attr := range realized.Attrs {
-			if attr.Key != "" {
-				t.Errorf("Expected empty group key, got %q", attr.Key)
+		for i, key := range []string{"attr", "key"} {
+			if attr := realized.Attrs[i]; attr.Key != key || attr.Value.Kind() != slog.KindString {
+				t.Errorf("Expected top-level string attribute %q, got %v", key, attr)
 			}
-			if attr.Value.Kind() != slog.KindGroup {
-				t.Errorf("Expected group kind, got %v", attr.Value.Kind())
+		}
+	})
+
+	t.Run("NormalizesAttrs", func(t *testing.T) {
+		record := Record{
+			Attrs: []slog.Attr{
+				{},
+				slog.Group("", slog.String("inline", "a"), slog.Group("", slog.Int("deeper", 1))),
+				slog.Group("empty"),
+				slog.Group("nested", slog.Group("empty"), slog.Attr{}),
+				slog.Any("lazy", groupValuer{}),
+				slog.Int("kept", 2),
+			},
+			Journal: OperationJournal{
+				{Type: OpAttrs, Attrs: []slog.Attr{{}, slog.String("global", "g")}},
+				{Type: OpGroup, Group: "unused"},
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.Group("")}},
+			},
+		}
+
+		realized := record.Realize()
+
+		// Only global survives outside the group; the unused group holds the record attrs
+		if len(realized.Attrs) != 2 || realized.Attrs[0].Key != "global" || realized.Attrs[1].Key != "unused" {
+			t.Fatalf("Unexpected attributes: %v", realized.Attrs)
+		}
+		var keys []string
+		for _, attr := range realized.Attrs[1].Value.Group() {
+			keys = append(keys, attr.Key)
+		}
+		if got := strings.Join(keys, ","); got != "inline,deeper,lazy,kept" {
+			t.Errorf("Expected inline,deeper,lazy,kept, got %s", got)
+		}
+	})
+
+	t.Run("DropsEmptyGroups", func(t *testing.T) {
+		record := Record{
+			Message: "no attrs",
+			Journal: OperationJournal{
+				{Type: OpGroup, Group: "outer"},
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.String("a", "b")}},
+				{Type: OpGroup, Group: "inner"},
+			},
+		}
+
+		realized := record.Realize()
+		if len(realized.Attrs) != 1 || realized.Attrs[0].Key != "outer" {
+			t.Fatalf("Expected only the outer group, got %v", realized.Attrs)
+		}
+		if members := realized.Attrs[0].Value.Group(); len(members) != 1 || members[0].Key != "a" {
+			t.Errorf("Expected the inner group to be dropped, got %v", members)
+		}
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		record := Record{
+			Attrs: []slog.Attr{slog.String("msg", "value")},
+			Journal: OperationJournal{
+				{Type: OpAttrs, Attrs: []slog.Attr{slog.String("global", "value")}},
+				{Type: OpGroup, Group: "api"},
+			},
+		}
+
+		realized := record.Realize()
+		if realized.Journal != nil {
+			t.Errorf("Expected no journal on the realized record, got %v", realized.Journal)
+		}
+		again := realized.Realize()
+		if len(again.Attrs) != len(realized.Attrs) {
+			t.Fatalf("Expected %d attributes, got %d", len(realized.Attrs), len(again.Attrs))
+		}
+		for i := range again.Attrs {
+			if !again.Attrs[i].Equal(realized.Attrs[i]) {
+				t.Errorf("Attribute %d changed: %v != %v", i, again.Attrs[i], realized.Attrs[i])
 			}
 		}
 	})
@@ -461,101 +536,6 @@ func flattenAttrs(attr slog.Attr, prefix string, result map[string]any) {
 	}
 }
 
-func TestApplyGroups(t *testing.T) {
-	t.Run("NoGroups", func(t *testing.T) {
-		attr := slog.String("key", "value")
-		result := applyGroups(attr, nil)
-
-		if result.Key != "key" {
-			t.Errorf("Expected key 'key', got %q", result.Key)
-		}
-	})
-
-	t.Run("EmptyGroups", func(t *testing.T) {
-		attr := slog.String("key", "value")
-		result := applyGroups(attr, []string{})
-
-		if result.Key != "key" {
-			t.Errorf("Expected key 'key', got %q", result.Key)
-		}
-	})
-
-	t.Run("SingleGroup", func(t *testing.T) {
-		attr := slog.String("key", "value")
-		result := applyGroups(attr, []string{"group1"})
-
-		if result.Key != "group1" {
-			t.Errorf("Expected key 'group1', got %q", result.Key)
-		}
-
-		if result.Value.Kind() != slog.KindGroup {
-			t.Errorf("Expected group value, got %v", result.Value.Kind())
-		}
-	})
-
-	t.Run("NestedGroups", func(t *testing.T) {
-		attr := slog.String("key", "value")
-		result := applyGroups(attr, []string{"outer", "inner"})
-
-		if result.Key != "outer" {
-			t.Errorf("Expected key 'outer', got %q", result.Key)
-		}
-
-		if result.Value.Kind() != slog.KindGroup {
-			t.Errorf("Expected group value, got %v", result.Value.Kind())
-		}
-
-		outerGroup := result.Value.Group()
-		if len(outerGroup) != 1 {
-			t.Fatalf("Expected 1 item in outer group, got %d", len(outerGroup))
-		}
-
-		if outerGroup[0].Key != "inner" {
-			t.Errorf("Expected inner key 'inner', got %q", outerGroup[0].Key)
-		}
-	})
-
-	t.Run("DeeplyNestedGroups", func(t *testing.T) {
-		attr := slog.Int("count", 42)
-		result := applyGroups(attr, []string{"level1", "level2", "level3", "level4"})
-
-		// Verify top level
-		if result.Key != "level1" {
-			t.Errorf("Expected key 'level1', got %q", result.Key)
-		}
-
-		// Navigate through all levels
-		current := result
-		for i, expectedKey := range []string{"level1", "level2", "level3", "level4"} {
-			if current.Key != expectedKey {
-				t.Errorf("Level %d: expected key %q, got %q", i, expectedKey, current.Key)
-			}
-
-			if i < 3 { // Not the last level
-				if current.Value.Kind() != slog.KindGroup {
-					t.Errorf("Level %d: expected group kind, got %v", i, current.Value.Kind())
-				}
-				group := current.Value.Group()
-				if len(group) != 1 {
-					t.Fatalf("Level %d: expected 1 item in group, got %d", i, len(group))
-				}
-				current = group[0]
-			} else { // Last level should contain the actual attribute
-				group := current.Value.Group()
-				if len(group) != 1 {
-					t.Fatalf("Final level: expected 1 item in group, got %d", len(group))
-				}
-				if group[0].Key != "count" {
-					t.Errorf("Expected final attribute key 'count', got %q", group[0].Key)
-				}
-				if group[0].Value.Int64() != 42 {
-					t.Errorf("Expected final value 42, got %d", group[0].Value.Int64())
-				}
-			}
-		}
-	})
-}
-
 func BenchmarkNewRecord(b *testing.B) {
 	fixedTime := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
 	ctx := b.Context()
@@ -673,37 +653,136 @@ func BenchmarkRecordRealize(b *testing.B) {
 	})
 }
 
-func BenchmarkApplyGroups(b *testing.B) {
-	attr := slog.String("key", "value")
+func TestRecordFind(t *testing.T) {
+	record := Record{
+		Attrs: []slog.Attr{
+			slog.String("global", "value"),
+			slog.Group("api",
+				slog.String("user", "123"),
+				slog.Group("", slog.Int("inline", 7)),
+			),
+			slog.Group("", slog.String("flat", "yes")),
+			slog.Any("lazy", lazyValue("resolved")),
+			slog.String("dup", "first"),
+			slog.String("dup", "second"),
+		},
+	}
 
-	b.Run("NoGroups", func(b *testing.B) {
-		b.ReportAllocs()
-		for b.Loop() {
-			_ = applyGroups(attr, nil)
-		}
-	})
+	cases := []struct {
+		name     string
+		path     []string
+		expected string
+		found    bool
+	}{
+		{"TopLevel", []string{"global"}, "value", true},
+		{"Nested", []string{"api", "user"}, "123", true},
+		{"InlineGroupInGroup", []string{"api", "inline"}, "7", true},
+		{"InlineGroupTopLevel", []string{"flat"}, "yes", true},
+		{"LogValuerResolved", []string{"lazy"}, "resolved", true},
+		{"LastDuplicateWins", []string{"dup"}, "second", true},
+		{"Missing", []string{"missing"}, "", false},
+		{"MissingNested", []string{"api", "missing"}, "", false},
+		{"PathThroughScalar", []string{"global", "x"}, "", false},
+		{"EmptyPath", nil, "", false},
+	}
 
-	b.Run("SingleGroup", func(b *testing.B) {
-		groups := []string{"group1"}
-		b.ReportAllocs()
-		for b.Loop() {
-			_ = applyGroups(attr, groups)
-		}
-	})
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, found := record.Find(tc.path...)
+			if found != tc.found {
+				t.Fatalf("Expected found=%v, got %v", tc.found, found)
+			}
+			if found && value.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, value.String())
+			}
+		})
+	}
 
-	b.Run("ThreeGroups", func(b *testing.B) {
-		groups := []string{"level1", "level2", "level3"}
-		b.ReportAllocs()
-		for b.Loop() {
-			_ = applyGroups(attr, groups)
+	t.Run("GroupValue", func(t *testing.T) {
+		value, found := record.Find("api")
+		if !found || value.Kind() != slog.KindGroup {
+			t.Errorf("Expected group value for api, got %v", value)
 		}
 	})
+}
 
-	b.Run("FiveGroups", func(b *testing.B) {
-		groups := []string{"level1", "level2", "level3", "level4", "level5"}
-		b.ReportAllocs()
-		for b.Loop() {
-			_ = applyGroups(attr, groups)
+func TestRecordFindRealized(t *testing.T) {
+	record := Record{
+		Attrs: []slog.Attr{slog.String("id", "2")},
+		Journal: OperationJournal{
+			{Type: OpAttrs, Attrs: []slog.Attr{slog.String("service", "auth"), slog.String("id", "1")}},
+			{Type: OpGroup, Group: "req"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		path     []string
+		expected string
+		found    bool
+	}{
+		{"JournalAttr", []string{"service"}, "auth", true},
+		{"BeforeGroup", []string{"id"}, "1", true},
+		{"InGroup", []string{"req", "id"}, "2", true},
+		{"Missing", []string{"req", "service"}, "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, found := record.FindRealized(tc.path...)
+			if found != tc.found || (found && value.String() != tc.expected) {
+				t.Errorf("Expected %q found=%v, got %q found=%v", tc.expected, tc.found, value.String(), found)
+			}
+		})
+	}
+
+	t.Run("NoGroups", func(t *testing.T) {
+		flat := Record{
+			Attrs:   []slog.Attr{slog.String("user", "bob")},
+			Journal: OperationJournal{{Type: OpAttrs, Attrs: []slog.Attr{slog.String("service", "auth")}}},
+		}
+		for path, want := range map[string]string{"user": "bob", "service": "auth"} {
+			if v, ok := flat.FindRealized(path); !ok || v.String() != want {
+				t.Errorf("%s: expected %q, got %q", path, want, v.String())
+			}
 		}
 	})
 }
+
+func TestRecordMergeContext(t *testing.T) {
+	record := Record{
+		Attrs:   []slog.Attr{slog.String("user", "bob")},
+		Journal: OperationJournal{{Type: OpGroup, Group: "req"}},
+		Context: []slog.Attr{slog.String("trace_id", "abc")},
+	}
+
+	merged := record.MergeContext()
+	if merged.Context != nil {
+		t.Errorf("Expected no context after merging, got %v", merged.Context)
+	}
+	realized := merged.Realize()
+	if v, ok := realized.Find("trace_id"); !ok || v.String() != "abc" {
+		t.Errorf("Expected top-level trace_id=abc, got %v (found %v)", v, ok)
+	}
+	if v, ok := realized.Find("req", "user"); !ok || v.String() != "bob" {
+		t.Errorf("Expected req.user=bob, got %v (found %v)", v, ok)
+	}
+	if len(record.Journal) != 1 || record.Context == nil {
+		t.Error("MergeContext modified the record")
+	}
+
+	plain := Record{Attrs: []slog.Attr{slog.String("user", "bob")}}
+	if merged := plain.MergeContext(); merged.Journal != nil {
+		t.Errorf("Expected no journal for a record without context, got %v", merged.Journal)
+	}
+}
+
+type lazyValue string
+
+func (v lazyValue) LogValue() slog.Value { return slog.StringValue(string(v)) }
+
+// groupValuer is a LogValuer that resolves to a group
+type groupValuer struct{}
+
+func (groupValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("x", 1))
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RouterOption configures a Router
+type RouterOption func(*Router)
+
+// route is a store and the records it receives
+type route struct {
+	name  string
+	match func(*Record) bool
+	store *MemStorage
+}
+
+// Router splits records between several stores, each with its own retention, by
+// rules on the realized record: for example errors kept for a day, debug records
+// capped at a thousand, and audit events kept without limit:
+//
+//	router := storage.NewRouter(
+//		storage.WithRoute("errors", func(r *storage.Record) bool { return r.Level >= slog.LevelError },
+//			storage.WithMaxAge(24*time.Hour)),
+//		storage.WithRoute("debug", func(r *storage.Record) bool { return r.Level < slog.LevelInfo },
+//			storage.WithMaxSize(1000)),
+//		storage.WithRoute("audit", query.MustCompile(`component == "audit"`).Match),
+//	)
+//	collector := loglater.NewLogCollector(handler, loglater.WithStorage(router))
+//
+// The Router numbers records itself, so a record keeps the same sequence number in
+// every store it is routed to, and reads merge the stores into one time-ordered view
+// without duplicates.
+type Router struct {
+	mu         sync.Mutex // serializes Append, so stores receive records in sequence order
+	lastSeq    uint64
+	routes     []route
+	firstMatch bool
+	realize    bool
+
+	dedup       bool
+	dedupWindow time.Duration
+	recent      []Record // records routed within the dedup window, oldest first
+}
+
+// NewRouter creates a Router with the routes given by WithRoute
+func NewRouter(opts ...RouterOption) *Router {
+	r := &Router{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithRoute adds a store, created with the options, that receives the records for
+// which match returns true. Match receives the realized record, so attributes added
+// with WithAttrs, such as component=db, can be matched; a compiled query expression's
+// Match method can be used directly. A nil match receives every record.
+//
+// WithDedup is ignored in the options, as stores deduplicating on their own would
+// hold different copies of the same records; use WithRouterDedup instead.
+func WithRoute(name string, match func(*Record) bool, opts ...Option) RouterOption {
+	return func(r *Router) {
+		store := NewRecordStorage(opts...)
+		store.keepSeq = true
+		store.dedup = false
+		r.routes = append(r.routes, route{name: name, match: match, store: store})
+		if match != nil {
+			r.realize = true
+		}
+	}
+}
+
+// WithFirstMatch sends each record only to the first route that matches, in the
+// order the routes were added, instead of every route that matches. A final route
+// with a nil match then receives the records no other route took.
+func WithFirstMatch() RouterOption {
+	return func(r *Router) {
+		r.firstMatch = true
+	}
+}
+
+// WithRouterDedup collapses repeats of a record, as WithDedup does for a single
+// store, before they are routed, so that every store holding the record counts the
+// repeat and the merged view shows one record
+func WithRouterDedup(window time.Duration) RouterOption {
+	return func(r *Router) {
+		r.dedup = true
+		r.dedupWindow = window
+	}
+}
+
+// WithRouterSequenceStart sets the sequence number after which the Router starts
+// numbering records, like WithSequenceStart for a single store, so that a fresh
+// Router can continue after a checkpoint persisted by a previous process.
+func WithRouterSequenceStart(seq uint64) RouterOption {
+	return func(r *Router) {
+		r.lastSeq = seq
+	}
+}
+
+// Store returns the store of the named route, or nil if there is none
+func (r *Router) Store(name string) *MemStorage {
+	for _, rt := range r.routes {
+		if rt.name == name {
+			return rt.store
+		}
+	}
+	return nil
+}
+
+// Append assigns the record the next sequence number and appends it to the stores
+// of the matching routes. A record that matches no route is dropped.
+func (r *Router) Append(record *Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dedup && r.collapseRepeat(record) {
+		return
+	}
+
+	r.lastSeq++
+	stored := *record
+	stored.Seq = r.lastSeq
+	if r.dedup {
+		r.recent = append(r.recent, stored)
+	}
+
+	var realized Record
+	if r.realize {
+		realized = stored.Realize()
+	}
+	for _, rt := range r.routes {
+		if rt.match != nil && !rt.match(&realized) {
+			continue
+		}
+		rt.store.Append(&stored)
+		if r.firstMatch {
+			return
+		}
+	}
+}
+
+// collapseRepeat collapses the record into the stores holding a recent record that
+// it repeats, and reports whether any did. The caller must hold the lock.
+func (r *Router) collapseRepeat(record *Record) bool {
+	if r.dedupWindow == 0 {
+		r.recent = r.recent[max(len(r.recent)-1, 0):]
+	} else {
+		cutoff := record.Time.Add(-r.dedupWindow)
+		i := slices.IndexFunc(r.recent, func(recent Record) bool { return !recent.Time.Before(cutoff) })
+		if i < 0 {
+			i = len(r.recent)
+		}
+		r.recent = slices.Delete(r.recent, 0, i)
+	}
+
+	for i := len(r.recent) - 1; i >= 0; i-- {
+		if !sameRecord(&r.recent[i], record) {
+			continue
+		}
+		collapsed := false
+		for _, rt := range r.routes {
+			if rt.store.collapseInto(r.recent[i].Seq, record) {
+				collapsed = true
+			}
+		}
+		if collapsed {
+			return true
+		}
+		// Every copy was removed, so the repeat is stored as a new record
+		r.recent = slices.Delete(r.recent, i, i+1)
+		return false
+	}
+	return false
+}
+
+// GetAll returns the records of all stores, merged in time order. Records with the
+// same time are in sequence order.
+func (r *Router) GetAll() []Record {
+	return r.merge(compareTime, func(s *MemStorage) []Record { return s.GetAll() })
+}
+
+// GetSince returns the records of all stores with a sequence number greater than
+// seq, merged in sequence order, so that the last record's sequence number is a
+// cursor for the next call
+func (r *Router) GetSince(seq uint64) []Record {
+	return r.merge(compareSeq, func(s *MemStorage) []Record { return s.GetSince(seq) })
+}
+
+// Lookup returns the records of all stores whose realized attribute at the dotted
+// path has the value, merged in time order. Each store answers from its own
+// indexes, given by WithIndex in its route options.
+func (r *Router) Lookup(path string, value any) []Record {
+	return r.merge(compareTime, func(s *MemStorage) []Record { return s.Lookup(path, value) })
+}
+
+// Remove deletes the records with the sequence numbers from every store, and
+// returns the number of records removed. A record routed to several stores counts
+// once for each.
+func (r *Router) Remove(seqs ...uint64) int {
+	removed := 0
+	for _, rt := range r.routes {
+		removed += rt.store.Remove(seqs...)
+	}
+	return removed
+}
+
+// Claim claims the records of all stores, as MemStorage.Claim does, and returns them
+// merged in time order. The stores are claimed together, so a record routed to
+// several stores is handed to only one caller.
+func (r *Router) Claim() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.merge(compareTime, func(s *MemStorage) []Record { return s.Claim() })
+}
+
+// Release ends the claims on the records in every store
+func (r *Router) Release(seqs ...uint64) {
+	for _, rt := range r.routes {
+		rt.store.Release(seqs...)
+	}
+}
+
+// RemoveOccurrences removes the occurrences seen of the records from every store,
+// as MemStorage.RemoveOccurrences does
+func (r *Router) RemoveOccurrences(seen map[uint64]int) int {
+	removed := 0
+	for _, rt := range r.routes {
+		removed += rt.store.RemoveOccurrences(seen)
+	}
+	return removed
+}
+
+// merge reads every store and combines the results in the order given by compare,
+// keeping one copy of records routed to several stores. Copies share their time and
+// sequence number, so they end up next to each other in either order.
+func (r *Router) merge(compare func(a, b Record) int, read func(*MemStorage) []Record) []Record {
+	if len(r.routes) == 1 {
+		result := read(r.routes[0].store)
+		slices.SortStableFunc(result, compare)
+		return result
+	}
+
+	result := make([]Record, 0)
+	for _, rt := range r.routes {
+		result = append(result, read(rt.store)...)
+	}
+	slices.SortStableFunc(result, compare)
+	return slices.CompactFunc(result, func(a, b Record) bool {
+		return a.Seq == b.Seq
+	})
+}
+
+// compareSeq orders records by sequence number
+func compareSeq(a, b Record) int {
+	return cmp.Compare(a.Seq, b.Seq)
+}
+
+// compareTime orders records by time, then by sequence number
+func compareTime(a, b Record) int {
+	if c := a.Time.Compare(b.Time); c != 0 {
+		return c
+	}
+	return compareSeq(a, b)
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+// seqs returns the sequence numbers of the records
+func seqs(records []Record) []uint64 {
+	result := make([]uint64, len(records))
+	for i, r := range records {
+		result[i] = r.Seq
+	}
+	return result
+}
+
+func TestRouter(t *testing.T) {
+	isError := func(r *Record) bool { return r.Level >= slog.LevelError }
+	isDB := func(r *Record) bool {
+		v, ok := r.Find("component")
+		return ok && v.String() == "db"
+	}
+	db := OperationJournal{{Type: OpAttrs, Attrs: []slog.Attr{slog.String("component", "db")}}}
+
+	setup := func(opts ...RouterOption) *Router {
+		opts = append([]RouterOption{
+			WithRoute("errors", isError),
+			WithRoute("db", isDB, WithMaxSize(2)),
+		}, opts...)
+		router := NewRouter(opts...)
+		router.Append(&Record{Level: slog.LevelInfo, Message: "1"})
+		router.Append(&Record{Level: slog.LevelError, Message: "2", Journal: db})
+		router.Append(&Record{Level: slog.LevelInfo, Message: "3", Journal: db})
+		router.Append(&Record{Level: slog.LevelError, Message: "4"})
+		router.Append(&Record{Level: slog.LevelInfo, Message: "5", Journal: db})
+		return router
+	}
+
+	t.Run("RoutesByRule", func(t *testing.T) {
+		router := setup()
+		if got := seqs(router.Store("errors").GetAll()); !slices.Equal(got, []uint64{2, 4}) {
+			t.Errorf("errors: expected 2,4, got %v", got)
+		}
+		// The db store keeps its own retention of two records
+		if got := seqs(router.Store("db").GetAll()); !slices.Equal(got, []uint64{3, 5}) {
+			t.Errorf("db: expected 3,5, got %v", got)
+		}
+		if router.Store("missing") != nil {
+			t.Error("expected nil for an unknown route")
+		}
+	})
+
+	t.Run("MergesWithoutDuplicates", func(t *testing.T) {
+		router := setup()
+		if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{2, 3, 4, 5}) {
+			t.Errorf("expected 2,3,4,5 without duplicates, got %v", got)
+		}
+		if got := seqs(router.GetSince(3)); !slices.Equal(got, []uint64{4, 5}) {
+			t.Errorf("expected 4,5, got %v", got)
+		}
+	})
+
+	t.Run("MergesInTimeOrder", func(t *testing.T) {
+		base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		router := NewRouter(WithRoute("errors", isError), WithRoute("all", nil))
+		router.Append(&Record{Time: base.Add(2 * time.Second), Level: slog.LevelInfo})
+		router.Append(&Record{Time: base, Level: slog.LevelError})
+		router.Append(&Record{Time: base.Add(time.Second), Level: slog.LevelInfo})
+		router.Append(&Record{Time: base.Add(time.Second), Level: slog.LevelInfo})
+
+		if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{2, 3, 4, 1}) {
+			t.Errorf("expected time order 2,3,4,1, got %v", got)
+		}
+		// Cursors need sequence order
+		if got := seqs(router.GetSince(1)); !slices.Equal(got, []uint64{2, 3, 4}) {
+			t.Errorf("expected sequence order 2,3,4, got %v", got)
+		}
+	})
+
+	t.Run("SequenceStart", func(t *testing.T) {
+		router := setup(WithRouterSequenceStart(100))
+		if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{102, 103, 104, 105}) {
+			t.Errorf("expected numbering after 100, got %v", got)
+		}
+	})
+
+	t.Run("FirstMatch", func(t *testing.T) {
+		router := setup(WithFirstMatch(), WithRoute("rest", nil))
+		if got := seqs(router.Store("db").GetAll()); !slices.Equal(got, []uint64{3, 5}) {
+			t.Errorf("db: expected 3,5, got %v", got)
+		}
+		if got := seqs(router.Store("rest").GetAll()); !slices.Equal(got, []uint64{1}) {
+			t.Errorf("rest: expected only the unmatched record, got %v", got)
+		}
+		if got := seqs(router.Store("errors").GetAll()); !slices.Equal(got, []uint64{2, 4}) {
+			t.Errorf("errors: expected 2,4, got %v", got)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		router := setup()
+		if n := router.Remove(2, 3); n != 2 {
+			t.Errorf("expected 2 removed, got %d", n)
+		}
+		if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{4, 5}) {
+			t.Errorf("expected 4,5, got %v", got)
+		}
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		router := setup()
+		if got := seqs(router.Claim()); !slices.Equal(got, []uint64{2, 3, 4, 5}) {
+			t.Errorf("expected 2,3,4,5 claimed once each, got %v", got)
+		}
+		if got := router.Claim(); len(got) != 0 {
+			t.Errorf("expected nothing left to claim, got %v", seqs(got))
+		}
+		router.Release(2)
+		if got := seqs(router.Claim()); !slices.Equal(got, []uint64{2}) {
+			t.Errorf("expected the released record 2, got %v", got)
+		}
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		router := NewRouter(
+			WithRoute("errors", isError, WithIndex("request")),
+			WithRoute("all", nil),
+		)
+		router.Append(&Record{Level: slog.LevelError, Attrs: []slog.Attr{slog.String("request", "a")}})
+		router.Append(&Record{Level: slog.LevelInfo, Attrs: []slog.Attr{slog.String("request", "a")}})
+		router.Append(&Record{Level: slog.LevelInfo, Attrs: []slog.Attr{slog.String("request", "b")}})
+
+		if got := seqs(router.Lookup("request", "a")); !slices.Equal(got, []uint64{1, 2}) {
+			t.Errorf("expected 1,2, got %v", got)
+		}
+	})
+
+	t.Run("IndependentRetention", func(t *testing.T) {
+		router := NewRouter(
+			WithRoute("recent", nil, WithMaxAge(time.Minute)),
+			WithRoute("all", nil),
+		)
+		router.Append(&Record{Time: time.Now().Add(-time.Hour), Message: "old"})
+		router.Append(&Record{Time: time.Now(), Message: "new"})
+
+		if got := seqs(router.Store("recent").GetAll()); !slices.Equal(got, []uint64{2}) {
+			t.Errorf("recent: expected 2, got %v", got)
+		}
+		if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{1, 2}) {
+			t.Errorf("expected the merged view to keep 1,2, got %v", got)
+		}
+	})
+	t.Run("Dedup", func(t *testing.T) {
+		base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		appendRepeats := func(router *Router) {
+			router.Append(&Record{Time: base, Level: slog.LevelError, Message: "a"})
+			router.Append(&Record{Time: base.Add(time.Second), Level: slog.LevelError, Message: "a"})
+			router.Append(&Record{Time: base.Add(2 * time.Second), Level: slog.LevelInfo, Message: "b"})
+			router.Append(&Record{Time: base.Add(3 * time.Second), Level: slog.LevelError, Message: "a"})
+		}
+
+		t.Run("AtRouter", func(t *testing.T) {
+			router := NewRouter(
+				WithRoute("errors", isError),
+				WithRoute("all", nil),
+				WithRouterDedup(time.Minute),
+			)
+			appendRepeats(router)
+
+			records := router.GetAll()
+			if got := seqs(records); !slices.Equal(got, []uint64{1, 2}) {
+				t.Fatalf("expected 1,2, got %v", got)
+			}
+			for _, name := range []string{"errors", "all"} {
+				stored := router.Store(name).GetAll()
+				if stored[0].Count != 3 || !stored[0].LastSeen.Equal(base.Add(3*time.Second)) {
+					t.Errorf("%s: expected a collapsed three times, got %+v", name, stored[0])
+				}
+			}
+		})
+
+		t.Run("ConsecutiveRepeats", func(t *testing.T) {
+			router := NewRouter(WithRoute("all", nil), WithRouterDedup(0))
+			appendRepeats(router)
+			if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{1, 2, 3}) {
+				t.Errorf("expected 1,2,3, got %v", got)
+			}
+		})
+
+		t.Run("IgnoredInRoute", func(t *testing.T) {
+			router := NewRouter(
+				WithRoute("errors", isError, WithDedup(time.Minute)),
+				WithRoute("all", nil),
+			)
+			appendRepeats(router)
+
+			// A deduplicating route would show a collapsed a and its copies
+			records := router.GetAll()
+			if got := seqs(records); !slices.Equal(got, []uint64{1, 2, 3, 4}) {
+				t.Errorf("expected every record once, got %v", got)
+			}
+			for _, r := range records {
+				if r.Count != 0 {
+					t.Errorf("expected no collapsed records, got %+v", r)
+				}
+			}
+		})
+
+		t.Run("AfterRemove", func(t *testing.T) {
+			router := NewRouter(WithRoute("all", nil), WithRouterDedup(time.Minute))
+			router.Append(&Record{Time: base, Message: "a"})
+			router.Remove(1)
+			router.Append(&Record{Time: base.Add(time.Second), Message: "a"})
+			if got := seqs(router.GetAll()); !slices.Equal(got, []uint64{2}) {
+				t.Errorf("expected the repeat stored as 2, got %v", got)
+			}
+		})
+	})
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// MaxBuckets bounds the number of buckets in a Stats histogram. A bucket width that
+// would need more over the span of the records is widened.
+const MaxBuckets = 10000
+
+// Stats summarizes a set of log records.
+type Stats struct {
+	Total     int                // number of occurrences counted, with each deduplicated record counting its Count
+	First     time.Time          // time of the earliest record, zero if no record has a time
+	Last      time.Time          // time of the latest record
+	ByLevel   map[slog.Level]int // counts by level
+	ByMessage map[string]int     // counts by message
+	ByAttr    map[string]int     // counts by value of the attribute set with WithStatsAttr
+	Histogram []Bucket           // counts over time, when WithStatsBucket is set
+	Bucket    time.Duration      // width of the histogram buckets, after any widening
+}
+
+// Bucket counts the records in one interval of a Stats histogram.
+type Bucket struct {
+	Start   time.Time
+	Count   int
+	ByLevel map[slog.Level]int
+}
+
+// StatsOption configures ComputeStats.
+type StatsOption func(*statsConfig)
+
+type statsConfig struct {
+	attrPath []string
+	bucket   time.Duration
+	since    time.Time
+	filter   func(*Record) bool
+}
+
+// WithStatsAttr counts records by the value of the attribute at the dotted path, such as
+// "component" or "http.status". Records without the attribute are not counted in ByAttr.
+func WithStatsAttr(path string) StatsOption {
+	return func(cfg *statsConfig) {
+		cfg.attrPath = strings.Split(path, ".")
+	}
+}
+
+// WithStatsBucket builds a histogram with buckets of the given width, aligned to
+// multiples of the width since the zero time. Empty buckets between the first and
+// last record are included. A width that would need more than MaxBuckets is widened
+// to the smallest multiple of it that does not.
+func WithStatsBucket(width time.Duration) StatsOption {
+	return func(cfg *statsConfig) {
+		if width > 0 {
+			cfg.bucket = width
+		}
+	}
+}
+
+// WithStatsSince counts only the records at or after t.
+func WithStatsSince(t time.Time) StatsOption {
+	return func(cfg *statsConfig) {
+		cfg.since = t
+	}
+}
+
+// WithStatsFilter counts only the records for which the filter returns true.
+func WithStatsFilter(filter func(*Record) bool) StatsOption {
+	return func(cfg *statsConfig) {
+		cfg.filter = filter
+	}
+}
+
+// ComputeStats aggregates the records. Records are expected to be realized, so that
+// WithStatsAttr and WithStatsFilter see the attributes added through the journal.
+// A deduplicated record counts once for each occurrence, all in the histogram
+// bucket of the time it was first seen, as the times between are not kept.
+func ComputeStats(records []Record, opts ...StatsOption) Stats {
+	cfg := &statsConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	stats := Stats{
+		ByLevel:   make(map[slog.Level]int),
+		ByMessage: make(map[string]int),
+		ByAttr:    make(map[string]int),
+	}
+	buckets := make(map[time.Time]*Bucket)
+
+	for i := range records {
+		r := &records[i]
+		if !cfg.since.IsZero() && r.Time.Before(cfg.since) {
+			continue
+		}
+		if cfg.filter != nil && !cfg.filter(r) {
+			continue
+		}
+
+		n := r.Occurrences()
+		stats.Total += n
+		stats.ByLevel[r.Level] += n
+		stats.ByMessage[r.Message] += n
+
+		if cfg.attrPath != nil {
+			if v, ok := r.Find(cfg.attrPath...); ok {
+				stats.ByAttr[v.String()] += n
+			}
+		}
+
+		if r.Time.IsZero() {
+			continue
+		}
+		if stats.First.IsZero() || r.Time.Before(stats.First) {
+			stats.First = r.Time
+		}
+		if r.Time.After(stats.Last) {
+			stats.Last = r.Time
+		}
+		if r.LastSeen.After(stats.Last) {
+			stats.Last = r.LastSeen
+		}
+
+		if cfg.bucket > 0 {
+			start := r.Time.Truncate(cfg.bucket)
+			b, ok := buckets[start]
+			if !ok {
+				b = &Bucket{Start: start, ByLevel: make(map[slog.Level]int)}
+				buckets[start] = b
+			}
+			b.Count += n
+			b.ByLevel[r.Level] += n
+		}
+	}
+
+	if len(buckets) > 0 {
+		width := histogramWidth(stats.First, stats.Last, cfg.bucket)
+		if width != cfg.bucket {
+			buckets = widenBuckets(buckets, width)
+		}
+		stats.Bucket = width
+
+		last := stats.Last.Truncate(width)
+		for start := stats.First.Truncate(width); !start.After(last); start = start.Add(width) {
+			if b, ok := buckets[start]; ok {
+				stats.Histogram = append(stats.Histogram, *b)
+			} else {
+				stats.Histogram = append(stats.Histogram, Bucket{Start: start, ByLevel: make(map[slog.Level]int)})
+			}
+		}
+	}
+
+	return stats
+}
+
+// histogramWidth returns the smallest multiple of width that covers first to last
+// in at most MaxBuckets buckets
+func histogramWidth(first, last time.Time, width time.Duration) time.Duration {
+	count := func(w time.Duration) int64 {
+		return int64(last.Truncate(w).Sub(first.Truncate(w))/w) + 1
+	}
+	n := count(width)
+	if n <= MaxBuckets {
+		return width
+	}
+	// Alignment can add a bucket to the estimate, so step up until it fits
+	factor := (n + MaxBuckets - 1) / MaxBuckets
+	for count(width*time.Duration(factor)) > MaxBuckets {
+		factor++
+	}
+	return width * time.Duration(factor)
+}
+
+// widenBuckets merges buckets into buckets of a multiple of their width
+func widenBuckets(buckets map[time.Time]*Bucket, width time.Duration) map[time.Time]*Bucket {
+	wide := make(map[time.Time]*Bucket, len(buckets))
+	for start, b := range buckets {
+		start = start.Truncate(width)
+		w, ok := wide[start]
+		if !ok {
+			w = &Bucket{Start: start, ByLevel: make(map[slog.Level]int)}
+			wide[start] = w
+		}
+		w.Count += b.Count
+		for level, n := range b.ByLevel {
+			w.ByLevel[level] += n
+		}
+	}
+	return wide
+}
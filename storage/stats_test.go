@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: base, Level: slog.LevelInfo, Message: "started", Attrs: []slog.Attr{slog.String("component", "api")}},
+		{Time: base.Add(30 * time.Second), Level: slog.LevelError, Message: "failed", Attrs: []slog.Attr{slog.String("component", "db")}},
+		{Time: base.Add(3 * time.Minute), Level: slog.LevelError, Message: "failed", Attrs: []slog.Attr{slog.String("component", "db")}},
+		{Time: base.Add(3*time.Minute + time.Second), Level: slog.LevelError, Message: "failed", Attrs: []slog.Attr{slog.String("component", "api")}},
+		{Level: slog.LevelWarn, Message: "no time"},
+	}
+
+	t.Run("Counts", func(t *testing.T) {
+		stats := ComputeStats(records)
+
+		if stats.Total != 5 {
+			t.Errorf("Expected 5 records, got %d", stats.Total)
+		}
+		if stats.ByLevel[slog.LevelError] != 3 || stats.ByLevel[slog.LevelInfo] != 1 || stats.ByLevel[slog.LevelWarn] != 1 {
+			t.Errorf("Unexpected level counts: %v", stats.ByLevel)
+		}
+		if stats.ByMessage["failed"] != 3 || stats.ByMessage["started"] != 1 {
+			t.Errorf("Unexpected message counts: %v", stats.ByMessage)
+		}
+		if len(stats.ByAttr) != 0 {
+			t.Errorf("Expected no attribute counts without WithStatsAttr, got %v", stats.ByAttr)
+		}
+		if !stats.First.Equal(base) || !stats.Last.Equal(base.Add(3*time.Minute+time.Second)) {
+			t.Errorf("Unexpected time range %v - %v", stats.First, stats.Last)
+		}
+		if stats.Histogram != nil {
+			t.Errorf("Expected no histogram without WithStatsBucket, got %v", stats.Histogram)
+		}
+	})
+
+	t.Run("CountsOccurrences", func(t *testing.T) {
+		deduped := []Record{
+			{Time: base, LastSeen: base.Add(time.Minute), Count: 3, Level: slog.LevelWarn, Message: "retrying"},
+			{Time: base.Add(time.Second), Level: slog.LevelInfo, Message: "connected"},
+		}
+		stats := ComputeStats(deduped, WithStatsBucket(time.Minute))
+
+		if stats.Total != 4 || stats.ByLevel[slog.LevelWarn] != 3 || stats.ByMessage["retrying"] != 3 {
+			t.Errorf("Expected each occurrence counted, got total %d, levels %v", stats.Total, stats.ByLevel)
+		}
+		if !stats.Last.Equal(base.Add(time.Minute)) {
+			t.Errorf("Expected the last seen time as Last, got %v", stats.Last)
+		}
+		if len(stats.Histogram) != 2 || stats.Histogram[0].Count != 4 {
+			t.Errorf("Expected the occurrences in the first bucket, got %v", stats.Histogram)
+		}
+	})
+
+	t.Run("ErrorsSinceByComponent", func(t *testing.T) {
+		stats := ComputeStats(records,
+			WithStatsSince(base.Add(time.Minute)),
+			WithStatsFilter(func(r *Record) bool { return r.Level >= slog.LevelError }),
+			WithStatsAttr("component"),
+		)
+
+		if stats.Total != 2 {
+			t.Errorf("Expected 2 records, got %d", stats.Total)
+		}
+		if stats.ByAttr["db"] != 1 || stats.ByAttr["api"] != 1 {
+			t.Errorf("Unexpected component counts: %v", stats.ByAttr)
+		}
+	})
+
+	t.Run("Histogram", func(t *testing.T) {
+		stats := ComputeStats(records, WithStatsBucket(time.Minute))
+
+		if len(stats.Histogram) != 4 {
+			t.Fatalf("Expected 4 buckets, got %d: %v", len(stats.Histogram), stats.Histogram)
+		}
+
+		expected := []int{2, 0, 0, 2}
+		for i, b := range stats.Histogram {
+			if !b.Start.Equal(base.Add(time.Duration(i) * time.Minute)) {
+				t.Errorf("Bucket %d: unexpected start %v", i, b.Start)
+			}
+			if b.Count != expected[i] {
+				t.Errorf("Bucket %d: expected %d records, got %d", i, expected[i], b.Count)
+			}
+		}
+		if stats.Histogram[3].ByLevel[slog.LevelError] != 2 {
+			t.Errorf("Expected 2 errors in last bucket, got %v", stats.Histogram[3].ByLevel)
+		}
+	})
+
+	t.Run("WidensTinyBuckets", func(t *testing.T) {
+		stats := ComputeStats(records, WithStatsBucket(time.Nanosecond))
+
+		if len(stats.Histogram) > MaxBuckets || stats.Bucket <= time.Nanosecond {
+			t.Fatalf("Expected at most %d widened buckets, got %d of %v", MaxBuckets, len(stats.Histogram), stats.Bucket)
+		}
+		total := 0
+		for _, b := range stats.Histogram {
+			total += b.Count
+		}
+		if total != 4 {
+			t.Errorf("Expected the 4 timed records in the histogram, got %d", total)
+		}
+		if kept := ComputeStats(records, WithStatsBucket(time.Minute)); kept.Bucket != time.Minute {
+			t.Errorf("Expected a small enough width to be kept, got %v", kept.Bucket)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		stats := ComputeStats(nil, WithStatsBucket(time.Minute))
+		if stats.Total != 0 || !stats.First.IsZero() || stats.Histogram != nil {
+			t.Errorf("Expected empty stats, got %+v", stats)
+		}
+		if stats.ByLevel == nil || stats.ByMessage == nil || stats.ByAttr == nil {
+			t.Error("Expected non-nil maps")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"cmp"
 	"context"
 	"slices"
 	"sync"
@@ -12,9 +13,16 @@ import (
 type MemStorage struct {
 	mu                  sync.RWMutex
 	records             []Record
+	lastSeq             uint64
+	indexes             map[string]*attrIndex
+	indexStale          int
 	cleanupFunc         CleanupFunc
 	asyncCleanupEnabled bool
 	cleanupDebounce     time.Duration
+	dedup               bool
+	dedupWindow         time.Duration
+	keepSeq             bool // keep sequence numbers assigned by a Router
+	claimed             map[uint64]struct{}
 
 	cleanupCh           chan struct{}
 	ctx                 context.Context
@@ -88,7 +96,9 @@ func (s *MemStorage) performCleanup() {
 	defer s.mu.Unlock()
 
 	if s.cleanupFunc != nil && len(s.records) > 0 {
+		before := len(s.records)
 		s.records = s.cleanupFunc(s.records)
+		s.noteRemoved(before - len(s.records))
 	}
 }
 
@@ -107,10 +117,25 @@ func (s *MemStorage) triggerCleanup() {
 	}
 }
 
-// Append adds a record to the storage.
+// Append adds a record to the storage, assigning it the next sequence number.
 func (s *MemStorage) Append(record *Record) {
 	s.mu.Lock()
-	s.records = append(s.records, *record)
+	if s.dedup {
+		if i := s.duplicateOf(record); i >= 0 {
+			s.records[i].collapse(record)
+			s.mu.Unlock()
+			return
+		}
+	}
+	if s.keepSeq && record.Seq > s.lastSeq {
+		s.lastSeq = record.Seq
+	} else {
+		s.lastSeq++
+	}
+	stored := *record
+	stored.Seq = s.lastSeq
+	s.records = append(s.records, stored)
+	s.indexRecord(&stored)
 	s.mu.Unlock()
 
 	// Trigger cleanup after append
@@ -125,3 +150,88 @@ func (s *MemStorage) GetAll() []Record {
 	defer s.mu.RUnlock()
 	return slices.Clone(s.records)
 }
+
+// GetSince returns a copy of all records with a sequence number greater than seq.
+func (s *MemStorage) GetSince(seq uint64) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.records[s.indexAfter(seq):])
+}
+
+// TakeAll removes all records from the storage and returns them.
+func (s *MemStorage) TakeAll() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := s.records
+	s.records = make([]Record, 0, cap(records))
+	s.rebuildIndexes()
+	return records
+}
+
+// Claim returns a copy of the records that are not claimed, and marks them claimed
+// until they are released, so that callers sharing the storage, such as concurrent
+// drains, are each handed different records.
+func (s *MemStorage) Claim() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimed == nil {
+		s.claimed = make(map[uint64]struct{})
+	}
+
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		if _, ok := s.claimed[r.Seq]; ok {
+			continue
+		}
+		s.claimed[r.Seq] = struct{}{}
+		records = append(records, r)
+	}
+	return records
+}
+
+// Release ends the claims on the records with the given sequence numbers, so that
+// the records that are still stored can be claimed again.
+func (s *MemStorage) Release(seqs ...uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, seq := range seqs {
+		delete(s.claimed, seq)
+	}
+}
+
+// Remove deletes the records with the given sequence numbers, and returns the number
+// of records removed. Sequence numbers that are no longer stored are ignored.
+func (s *MemStorage) Remove(seqs ...uint64) int {
+	if len(seqs) == 0 {
+		return 0
+	}
+	remove := make(map[uint64]struct{}, len(seqs))
+	for _, seq := range seqs {
+		remove[seq] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.records)
+	s.records = slices.DeleteFunc(s.records, func(r Record) bool {
+		_, ok := remove[r.Seq]
+		return ok
+	})
+	removed := before - len(s.records)
+	s.noteRemoved(removed)
+	return removed
+}
+
+// indexAfter returns the index of the first record with a sequence number
+// greater than seq. Records are kept in sequence order, so this is a binary search.
+// The caller must hold the lock.
+func (s *MemStorage) indexAfter(seq uint64) int {
+	i, found := slices.BinarySearchFunc(s.records, seq, func(r Record, target uint64) int {
+		return cmp.Compare(r.Seq, target)
+	})
+	if found {
+		i++
+	}
+	return i
+}
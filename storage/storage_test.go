@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"log/slog"
+	"slices"
 	"sync"
 	"testing"
 	"testing/synctest"
@@ -570,3 +571,124 @@ func TestStartCleanupWorker(t *testing.T) {
 		})
 	})
 }
+
+func TestRecordSequence(t *testing.T) {
+	t.Run("AssignsIncreasingSeq", func(t *testing.T) {
+		store := NewRecordStorage()
+		record := &Record{Message: "test"}
+		store.Append(record)
+		store.Append(record)
+
+		records := store.GetAll()
+		if records[0].Seq != 1 || records[1].Seq != 2 {
+			t.Errorf("Expected sequence 1,2, got %d,%d", records[0].Seq, records[1].Seq)
+		}
+		if record.Seq != 0 {
+			t.Errorf("Append should not modify the caller's record, got seq %d", record.Seq)
+		}
+	})
+
+	t.Run("WithSequenceStart", func(t *testing.T) {
+		store := NewRecordStorage(WithSequenceStart(100))
+		store.Append(&Record{Message: "test"})
+
+		if seq := store.GetAll()[0].Seq; seq != 101 {
+			t.Errorf("Expected seq 101, got %d", seq)
+		}
+	})
+
+	t.Run("GetSince", func(t *testing.T) {
+		store := NewRecordStorage(WithMaxSize(3))
+		for range 5 {
+			store.Append(&Record{Message: "test"})
+		}
+
+		cases := []struct {
+			since    uint64
+			expected []uint64
+		}{
+			{0, []uint64{3, 4, 5}},
+			{2, []uint64{3, 4, 5}},
+			{3, []uint64{4, 5}},
+			{5, []uint64{}},
+			{10, []uint64{}},
+		}
+
+		for _, tc := range cases {
+			records := store.GetSince(tc.since)
+			got := make([]uint64, 0, len(records))
+			for _, r := range records {
+				got = append(got, r.Seq)
+			}
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("GetSince(%d): expected %v, got %v", tc.since, tc.expected, got)
+			}
+		}
+	})
+}
+
+func TestRecordRemoval(t *testing.T) {
+	t.Run("TakeAll", func(t *testing.T) {
+		store := NewRecordStorage()
+		store.Append(&Record{Message: "a"})
+		store.Append(&Record{Message: "b"})
+
+		taken := store.TakeAll()
+		if len(taken) != 2 || taken[0].Message != "a" || taken[1].Message != "b" {
+			t.Fatalf("Expected a,b taken, got %v", taken)
+		}
+		if records := store.GetAll(); len(records) != 0 {
+			t.Errorf("Expected empty storage after TakeAll, got %d", len(records))
+		}
+
+		store.Append(&Record{Message: "c"})
+		if records := store.GetAll(); records[0].Seq != 3 {
+			t.Errorf("Expected numbering to continue at 3, got %d", records[0].Seq)
+		}
+		if taken[0].Message != "a" {
+			t.Error("Appending after TakeAll modified the taken records")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		store := NewRecordStorage()
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			store.Append(&Record{Message: msg})
+		}
+
+		if n := store.Remove(1, 3, 99); n != 2 {
+			t.Errorf("Expected 2 records removed, got %d", n)
+		}
+		if n := store.Remove(); n != 0 {
+			t.Errorf("Expected 0 records removed, got %d", n)
+		}
+
+		records := store.GetAll()
+		if len(records) != 2 || records[0].Message != "b" || records[1].Message != "d" {
+			t.Errorf("Expected b,d to remain, got %v", records)
+		}
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		store := NewRecordStorage()
+		store.Append(&Record{Message: "a"})
+		store.Append(&Record{Message: "b"})
+
+		claimed := store.Claim()
+		if len(claimed) != 2 {
+			t.Fatalf("Expected 2 records claimed, got %v", claimed)
+		}
+		store.Append(&Record{Message: "c"})
+		if again := store.Claim(); len(again) != 1 || again[0].Message != "c" {
+			t.Errorf("Expected only the unclaimed record c, got %v", again)
+		}
+		if records := store.GetAll(); len(records) != 3 {
+			t.Errorf("Expected claimed records to stay readable, got %d", len(records))
+		}
+
+		store.Release(2)
+		if again := store.Claim(); len(again) != 1 || again[0].Message != "b" {
+			t.Errorf("Expected the released record b, got %v", again)
+		}
+	})
+}